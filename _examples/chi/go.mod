@@ -0,0 +1,10 @@
+module github.com/kataras/methodoverride/_examples/chi
+
+go 1.23
+
+require (
+	github.com/go-chi/chi/v5 v5.3.2
+	github.com/kataras/methodoverride v0.0.2
+)
+
+replace github.com/kataras/methodoverride => ../../
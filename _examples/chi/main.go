@@ -0,0 +1,34 @@
+// Package main shows how to use the methodoverride wrapper with the chi router.
+//
+// chi matches routes by method, so the method override should run before
+// chi's router sees the request. Register it through the top-level router's
+// Use method (or wrap the whole router), never through With or Group:
+// inline middleware runs after the route was matched by its original method.
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kataras/methodoverride"
+)
+
+func main() {
+	http.ListenAndServe(":8080", newRouter())
+}
+
+func newRouter() http.Handler {
+	router := chi.NewRouter()
+	// Middleware registered by Use runs before routing.
+	router.Use(methodoverride.New())
+
+	router.Post("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("create item " + chi.URLParam(r, "id")))
+	})
+
+	router.Delete("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete item " + chi.URLParam(r, "id")))
+	})
+
+	return router
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kataras/methodoverride"
+)
+
+func TestChi(t *testing.T) {
+	handler := newRouter()
+
+	expectBody(t, handler, newFormRequest("/items/42", "_method=DELETE"), http.StatusOK, "delete item 42")
+	expectBody(t, handler, newFormRequest("/items/42", ""), http.StatusOK, "create item 42")
+
+	// Wrapping the whole router works too.
+	router := chi.NewRouter()
+	router.Delete("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete item " + chi.URLParam(r, "id")))
+	})
+
+	expectBody(t, methodoverride.New()(router), newFormRequest("/items/42", "_method=DELETE"), http.StatusOK, "delete item 42")
+
+	// Inline middleware runs after routing, so the POST request never reaches the DELETE route.
+	router = chi.NewRouter()
+	router.With(methodoverride.New()).Delete("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete item " + chi.URLParam(r, "id")))
+	})
+
+	expectBody(t, router, newFormRequest("/items/42", "_method=DELETE"), http.StatusMethodNotAllowed, "")
+}
+
+func newFormRequest(target, form string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func expectBody(t *testing.T, handler http.Handler, r *http.Request, expectedStatusCode int, expectedBody string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Code; expectedStatusCode != got {
+		t.Fatalf("%s: expected status code: %d but got %d", r.URL, expectedStatusCode, got)
+	}
+
+	b, _ := ioutil.ReadAll(w.Body)
+	if got := string(b); expectedBody != got {
+		t.Fatalf("%s: expected to receive '%s' but got '%s'", r.URL, expectedBody, got)
+	}
+}
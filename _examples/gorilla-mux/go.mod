@@ -0,0 +1,10 @@
+module github.com/kataras/methodoverride/_examples/gorilla-mux
+
+go 1.13
+
+require (
+	github.com/gorilla/mux v1.8.1
+	github.com/kataras/methodoverride v0.0.2
+)
+
+replace github.com/kataras/methodoverride => ../../
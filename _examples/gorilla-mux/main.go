@@ -0,0 +1,33 @@
+// Package main shows how to use the methodoverride wrapper with the gorilla/mux router.
+//
+// gorilla/mux runs the middleware registered through Router.Use
+// only after a route was matched by its original method,
+// so a POST request can never reach a route constrained by Methods("DELETE") that way.
+// Wrap the router itself, at the server level, instead.
+package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kataras/methodoverride"
+)
+
+func main() {
+	http.ListenAndServe(":8080", newHandler())
+}
+
+func newHandler() http.Handler {
+	router := mux.NewRouter()
+
+	router.HandleFunc("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("create item " + mux.Vars(r)["id"]))
+	}).Methods(http.MethodPost)
+
+	router.HandleFunc("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete item " + mux.Vars(r)["id"]))
+	}).Methods(http.MethodDelete)
+
+	// Wrap the router, do NOT use router.Use(methodoverride.New()).
+	return methodoverride.New()(router)
+}
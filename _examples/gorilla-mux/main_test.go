@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/kataras/methodoverride"
+)
+
+func TestGorillaMux(t *testing.T) {
+	handler := newHandler()
+
+	expectBody(t, handler, newOverrideRequest("/items/42", http.MethodDelete), http.StatusOK, "delete item 42")
+	expectBody(t, handler, newOverrideRequest("/items/42", ""), http.StatusOK, "create item 42")
+
+	// Router.Use middleware runs after routing, so the POST request never reaches the DELETE route.
+	router := mux.NewRouter()
+	router.Use(methodoverride.New())
+	router.HandleFunc("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete item " + mux.Vars(r)["id"]))
+	}).Methods(http.MethodDelete)
+
+	expectBody(t, router, newOverrideRequest("/items/42", http.MethodDelete), http.StatusMethodNotAllowed, "")
+}
+
+func newOverrideRequest(target, method string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, nil)
+	if method != "" {
+		r.Header.Set("X-HTTP-Method", method)
+	}
+	return r
+}
+
+func expectBody(t *testing.T, handler http.Handler, r *http.Request, expectedStatusCode int, expectedBody string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Code; expectedStatusCode != got {
+		t.Fatalf("%s: expected status code: %d but got %d", r.URL, expectedStatusCode, got)
+	}
+
+	b, _ := ioutil.ReadAll(w.Body)
+	if got := string(b); expectedBody != got {
+		t.Fatalf("%s: expected to receive '%s' but got '%s'", r.URL, expectedBody, got)
+	}
+}
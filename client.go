@@ -0,0 +1,82 @@
+package methodoverride
+
+import "net/http"
+
+// clientTransport is the client side of the method override, see `NewClientTransport`.
+type clientTransport struct {
+	base   http.RoundTripper
+	method string // the method the requests are sent with, e.g. "POST".
+	header string // if not empty, the header the real method is sent with.
+	query  string // if header is empty, the url parameter the real method is sent with.
+}
+
+// NewClientTransport returns a new http.RoundTripper, based on the http.DefaultTransport,
+// which sends the requests of methods other than "GET", "HEAD" and the overridable ones,
+// e.g. "DELETE", as the first method that can be overridden, e.g. "POST",
+// along with the real method on the first header or, if none, the first url parameter
+// of the given options, the inverse of the server-side method override wrapper.
+// It accepts the same options as the `New` package-level function.
+//
+// Use it to talk to servers which only accept method override-encoded requests
+// or to test them.
+//
+// Example Code:
+//
+//	client := &http.Client{Transport: NewClientTransport()}
+//	// sent as "POST" with the "X-HTTP-Method: DELETE" header.
+//	req, _ := http.NewRequest(http.MethodDelete, "http://localhost:8080/users/42", nil)
+//	client.Do(req)
+func NewClientTransport(opt ...Option) http.RoundTripper {
+	return WrapTransport(http.DefaultTransport, opt...)
+}
+
+// WrapTransport same as `NewClientTransport` but it wraps the "base" transport.
+func WrapTransport(base http.RoundTripper, opt ...Option) http.RoundTripper {
+	opts := newOptions(opt...)
+
+	t := &clientTransport{base: base}
+	if len(opts.methods) > 0 {
+		t.method = opts.methods[0]
+	}
+
+	if len(opts.headers) > 0 {
+		t.header = opts.headers[0]
+	} else if len(opts.queryParams) > 0 {
+		t.query = opts.queryParams[0]
+	}
+
+	return t
+}
+
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.needsOverride(req.Method) {
+		return t.base.RoundTrip(req)
+	}
+
+	// a RoundTripper should not modify the request.
+	r := req.Clone(req.Context())
+	r.Method = t.method
+
+	if t.header != "" {
+		r.Header.Set(t.header, req.Method)
+	} else {
+		q := r.URL.Query()
+		q.Set(t.query, req.Method)
+		r.URL.RawQuery = q.Encode()
+	}
+
+	return t.base.RoundTrip(r)
+}
+
+func (t *clientTransport) needsOverride(method string) bool {
+	if t.method == "" || (t.header == "" && t.query == "") {
+		return false
+	}
+
+	switch method {
+	case "", http.MethodGet, http.MethodHead, t.method:
+		return false
+	default:
+		return true
+	}
+}
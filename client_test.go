@@ -0,0 +1,54 @@
+package methodoverride
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientTransport(t *testing.T) {
+	tests := []struct {
+		opts     []Option
+		header   string
+		rawQuery string
+	}{
+		{nil, http.MethodDelete, "q=1"},
+		{[]Option{Only(Query("_method"))}, "", "_method=DELETE&q=1"},
+	}
+
+	for i, tt := range tests {
+		var sent *http.Request
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent = r
+			New(tt.opts...)(http.HandlerFunc(writeMethod)).ServeHTTP(w, r)
+		}))
+
+		client := &http.Client{Transport: NewClientTransport(tt.opts...)}
+
+		req, err := http.NewRequest(http.MethodDelete, srv.URL+"?q=1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Request = req
+
+		(&testie{t: t, resp: resp}).statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+		if sent.Method != http.MethodPost || sent.Header.Get("X-HTTP-Method") != tt.header || sent.URL.RawQuery != tt.rawQuery {
+			t.Fatalf("[%d] expected the request to be sent as POST with header: '%s' and query: '%s' but got %s with '%s' and '%s'",
+				i, tt.header, tt.rawQuery, sent.Method, sent.Header.Get("X-HTTP-Method"), sent.URL.RawQuery)
+		}
+
+		if req.Method != http.MethodDelete || req.Header.Get("X-HTTP-Method") != "" {
+			t.Fatalf("[%d] expected the original request to be left as it is", i)
+		}
+
+		// not overridden.
+		expect(t, http.MethodGet, srv.URL).statusCode(http.StatusOK).bodyEq(http.MethodGet)
+		srv.Close()
+	}
+}
@@ -13,6 +13,14 @@ The only requirement is the Go Programming Language:
 
     $ go get github.com/kataras/methodoverride
 
+Default Values
+
+The POST requests are checked for the "X-HTTP-Method", "X-HTTP-Method-Override" and "X-Method-Override" headers,
+the "_method" form field and the "_method" url query, see DefaultOptions.
+Methods longer than 32 characters are ignored, see MaxMethodLength.
+The request method is trimmed and uppercased before it is checked, see NormalizeMethod.
+The TRACE and CONNECT methods are never overridden with, see AllowDangerousMethods.
+
 */
 
 package methodoverride
@@ -0,0 +1,47 @@
+// Package echo provides a method override middleware for the echo web framework.
+//
+// The middleware runs before echo's router, see `Echo`,
+// so the routes are matched by the overridden method and path.
+package echo
+
+import (
+	"net/http"
+
+	"github.com/kataras/methodoverride"
+	"github.com/labstack/echo/v4"
+)
+
+// Echo returns a new method override middleware for echo.
+// It accepts the same options as the `methodoverride.New` package-level function
+// and rewrites the `c.Request().Method` (and context) the same way,
+// along with its URL when a getter rewrites the path, e.g. `methodoverride.PathParam`.
+//
+// echo matches routes by method, register it with `Pre`
+// so the method is overridden before the router runs:
+//
+//	e.Pre(Echo())
+func Echo(opt ...methodoverride.Option) echo.MiddlewareFunc {
+	mo := methodoverride.New(opt...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error
+			req := c.Request()
+			mo(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				// echo's router reads the method and the path of the original request value,
+				// the path may be rewritten too, e.g. by the PathParam getter.
+				req.Method = r.Method
+				req.URL = r.URL
+				req.RequestURI = r.RequestURI
+				c.SetRequest(r)
+				// the next handlers run inside the wrapper,
+				// e.g. the request body is still available while they read it.
+				err = next(c)
+			})).ServeHTTP(c.Response(), req)
+
+			// if the wrapper responded, e.g. with a 405 Method Not Allowed,
+			// the next handlers are never called.
+			return err
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package echo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/methodoverride"
+	"github.com/labstack/echo/v4"
+)
+
+func TestEcho(t *testing.T) {
+	e := echo.New()
+	e.Pre(Echo())
+	e.POST("/path", func(c echo.Context) error {
+		return c.String(http.StatusOK, "post resp")
+	})
+	e.DELETE("/path", func(c echo.Context) error {
+		return c.String(http.StatusOK, "delete resp")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/path", strings.NewReader("_method=DELETE"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	e.ServeHTTP(w, req)
+
+	if expected, got := "delete resp", w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/path", nil)
+	e.ServeHTTP(w, req)
+
+	if expected, got := "post resp", w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+}
+
+func TestEchoPath(t *testing.T) {
+	e := echo.New()
+	e.Pre(Echo(methodoverride.Only(methodoverride.PathParam(-1, true))))
+	e.POST("/users/1/delete", func(c echo.Context) error {
+		return c.String(http.StatusOK, "post resp")
+	})
+	e.DELETE("/users/1", func(c echo.Context) error {
+		return c.String(http.StatusOK, "delete resp")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/1/delete", nil))
+
+	if expected, got := "delete resp", w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+}
+
+func TestEchoShortCircuit(t *testing.T) {
+	e := echo.New()
+	e.Pre(Echo(methodoverride.RejectMethodsNotIn(http.MethodGet)))
+	e.POST("/path", func(c echo.Context) error {
+		return c.String(http.StatusOK, "post resp")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/path", nil))
+
+	if expected, got := http.StatusMethodNotAllowed, w.Code; expected != got {
+		t.Fatalf("expected status code: %d but got %d", expected, got)
+	}
+
+	if got := w.Body.String(); got != "" {
+		t.Fatalf("expected an empty body but got '%s'", got)
+	}
+}
+
+func TestEchoSpillToDisk(t *testing.T) {
+	e := echo.New()
+	e.Pre(Echo(methodoverride.SpillToDisk(4)))
+	e.DELETE("/path", func(c echo.Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+
+		return c.String(http.StatusOK, "delete "+string(b))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/path", strings.NewReader("_method=DELETE"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	e.ServeHTTP(w, req)
+
+	if expected, got := "delete _method=DELETE", w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+}
@@ -0,0 +1,45 @@
+// Package gin provides a method override middleware for the gin web framework.
+//
+// gin matches the routes before the middleware runs,
+// so it overrides the method of the request seen by the next handlers of the chain, see `Gin`.
+package gin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/methodoverride"
+)
+
+// Gin returns a new method override middleware for gin.
+// It accepts the same options as the `methodoverride.New` package-level function
+// and rewrites the `c.Request.Method` (and context) the same way.
+//
+// The next handlers of the chain run with the overridden request.
+// If the wrapper responds itself, e.g. with a 405 Method Not Allowed,
+// the chain is aborted and the next handlers are not called.
+//
+// Note that gin matches routes by method before any middleware runs,
+// so the route of the original method is used.
+// To let the overridden method be used for routing, wrap the engine instead:
+//
+//	http.ListenAndServe(":8080", methodoverride.New()(router))
+func Gin(opt ...methodoverride.Option) gin.HandlerFunc {
+	mo := methodoverride.New(opt...)
+
+	return func(c *gin.Context) {
+		served := false
+		mo(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			served = true
+			c.Request = r
+			// the next handlers run inside the wrapper,
+			// e.g. the request body is still available while they read it.
+			c.Next()
+		})).ServeHTTP(c.Writer, c.Request)
+
+		if !served {
+			// the wrapper responded, e.g. with a 405 Method Not Allowed.
+			c.Abort()
+		}
+	}
+}
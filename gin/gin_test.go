@@ -0,0 +1,92 @@
+package gin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/methodoverride"
+)
+
+func TestGin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Gin(methodoverride.SaveOriginalMethod("_originalMethod")))
+	router.POST("/path", func(c *gin.Context) {
+		c.String(http.StatusOK, "%s%v", c.Request.Method, c.Request.Context().Value("_originalMethod"))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/path", nil)
+	req.Header.Set("X-HTTP-Method", http.MethodDelete)
+	router.ServeHTTP(w, req)
+
+	if expected, got := http.MethodDelete+http.MethodPost, w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+
+	// Test wrapping the engine so the overridden method is used for routing.
+	router = gin.New()
+	router.DELETE("/path", func(c *gin.Context) {
+		c.String(http.StatusOK, "delete resp")
+	})
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/path", nil)
+	req.Header.Set("X-HTTP-Method", http.MethodDelete)
+	methodoverride.New()(router).ServeHTTP(w, req)
+
+	if expected, got := "delete resp", w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+}
+
+func TestGinShortCircuit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Gin(methodoverride.RejectMethodsNotIn(http.MethodGet)))
+	router.POST("/path", func(c *gin.Context) {
+		c.String(http.StatusOK, "post resp")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/path", nil))
+
+	if expected, got := http.StatusMethodNotAllowed, w.Code; expected != got {
+		t.Fatalf("expected status code: %d but got %d", expected, got)
+	}
+
+	if got := w.Body.String(); got != "" {
+		t.Fatalf("expected an empty body but got '%s'", got)
+	}
+}
+
+func TestGinSpillToDisk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Gin(methodoverride.SpillToDisk(4)))
+	router.POST("/path", func(c *gin.Context) {
+		b, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.String(http.StatusOK, "%s %s", c.Request.Method, b)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/path", strings.NewReader("_method=DELETE"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(w, req)
+
+	if expected, got := "DELETE _method=DELETE", w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+}
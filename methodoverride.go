@@ -3,15 +3,82 @@ package methodoverride
 import (
 	"bytes"
 	stdContext "context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
+	"net"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 type options struct {
-	getters                      []GetterFunc
+	getters                      []source
 	methods                      []string
-	saveOriginalMethodContextKey interface{} // if not nil original value will be saved.
+	targetMethods                []string                   // if not empty, the only methods to override with.
+	extensionTargetMethods       []string                   // allowed along with the target methods, if any.
+	methodMap                    map[string]string          // original method to forced method.
+	strictMethods                bool                       // if true, only known methods can be used to override with.
+	knownMethods                 []string                   // extra known methods.
+	fallbackMethod               string                     // if not empty, the method to override with when the resolved one is unknown.
+	saveOriginalMethodContextKey interface{}                // if not nil original value will be saved.
+	saveOriginalMethodHeader     string                     // if not empty, the request header the original value will be saved on.
+	markOverriddenContextKey     interface{}                // if not nil, true is saved on override.
+	saveResolvedMethodContextKey interface{}                // if not nil, the final method will be saved.
+	saveOverrideSourceContextKey interface{}                // if not nil, the source of the method to override with will be saved.
+	maxBodyScan                  int64                      // if positive, the max body bytes read on form detection.
+	noBodyRead                   bool                       // if true, the body is never read on form detection.
+	formFieldMaxLen              int64                      // if positive, the max declared body length read on form detection.
+	formBodyMethods              []string                   // if not nil, the only methods which their body is read on form detection.
+	allowReoverride              bool                       // if true, an already overridden request can be overridden again.
+	allowDangerousMethods        bool                       // if true, TRACE and CONNECT are valid methods to override with.
+	maxMethodLength              int                        // if > 0, longer methods to override with are ignored.
+	clearBodyForBodyless         bool                       // if true, the body is cleared when overriding with GET, HEAD or DELETE.
+	clearFormContentType         bool                       // if true, the Content-Type is cleared when a form method is GET, HEAD or DELETE.
+	echoHeader                   string                     // if not empty, the response header which documents the override.
+	allowedMethods               []string                   // if not empty, any other request method is rejected.
+	stopOnEmpty                  bool                       // if true, a present but empty source stops the getters chain.
+	stopAfterSources             []SourceKind               // a present source of these kinds stops the getters chain.
+	trustedProxies               []*net.IPNet               // if not empty, headers are checked only for requests coming from these networks.
+	errs                         []error                    // configuration errors, reported by NewStrict.
+	concurrentGetters            bool                       // if true, the custom getters run concurrently.
+	formParser                   FormParserFunc             // if not nil, it replaces the builtin form parsing.
+	routeChecker                 RouteCheckerFunc           // if not nil, it reports whether the route accepts the new method.
+	enabled                      func(*http.Request) bool   // if not nil and false, the request is passed through.
+	normalizeHeadToGet           bool                       // if true, the "HEAD" method is overridden with "GET".
+	spillThreshold               int64                      // if positive, larger bodies are buffered to a temporary file.
+	spills                       *spillFiles                // if not nil, the temporary files of the requests being served.
+	rejectHandler                func(*OverrideError)       // if not nil, it is notified about rejected overrides.
+	overrideHandler              func(*OverrideEvent)       // if not nil, it is notified about applied overrides.
+	dryRun                       bool                       // if true, overrides are resolved but not applied.
+	throttle                     *throttle                  // if not nil, it limits the overrides per client.
+	cacheParsedForm              bool                       // if true, the parsed form is saved on the request context.
+	corsRequestMethod            bool                       // if true, the preflight requested method is saved on the request context.
+	normalizeMethod              func(string) string        // if not nil, it normalizes the request method.
+	rules                        []pathRule                 // see `AddRule`.
+	serverVars                   ServerVarsFunc             // see `ServerVars`.
+	maxGetters                   int                        // if positive, the maximum number of getters.
+	bodyErrorHandler             func(*http.Request, error) // if not nil, it is notified about request body read errors.
+	varyAllHeaders               bool                       // if true, all header names are added to the Vary response header.
+	diagnosticsHeader            string                     // if not empty, the response header which traces the checked sources.
+	conditions                   []func(*http.Request) bool // all should pass to allow an override.
+	authorizers                  []AuthorizeFunc            // all should pass to apply an override.
+
+	// registered names of the builtin getters, for introspection.
+	headers     []string
+	headerKeys  []string // canonical header names.
+	formFields  []string
+	queryParams []string
 }
 
 func (o *options) configure(opts ...Option) {
@@ -20,6 +87,97 @@ func (o *options) configure(opts ...Option) {
 	}
 }
 
+func (o *options) config() Config {
+	// the slices are never nil, so they are encoded as empty JSON arrays.
+	c := Config{
+		Methods:            append([]string{}, o.methods...),
+		Headers:            append([]string{}, o.headers...),
+		FormFields:         append([]string{}, o.formFields...),
+		QueryParams:        append([]string{}, o.queryParams...),
+		SaveOriginalMethod: o.saveOriginalMethodContextKey != nil,
+		Sources:            o.sources(),
+		TargetMethods:      []string{},
+		FallbackMethod:     o.fallbackMethod,
+		MaxMethodLength:    o.maxMethodLength,
+		TrustedProxies:     make([]string, len(o.trustedProxies)),
+	}
+
+	if len(o.targetMethods) > 0 {
+		c.TargetMethods = append(append(c.TargetMethods, o.targetMethods...), o.extensionTargetMethods...)
+	}
+
+	for i, network := range o.trustedProxies {
+		c.TrustedProxies[i] = network.String()
+	}
+
+	if o.throttle != nil {
+		c.Throttle = &ThrottleConfig{Max: o.throttle.max, Per: o.throttle.per}
+	}
+
+	return c
+}
+
+func (o *options) sources() []SourceKind {
+	kinds := make([]SourceKind, len(o.getters))
+	for i, getter := range o.getters {
+		kinds[i] = getter.kind
+	}
+
+	return kinds
+}
+
+func (o *options) validate() error {
+	if len(o.errs) > 0 {
+		return o.errs[0]
+	}
+
+	for _, methods := range [][]string{o.methods, o.targetMethods, o.knownMethods, o.allowedMethods} {
+		for _, method := range methods {
+			if strings.TrimSpace(method) == "" {
+				return errors.New("methodoverride: empty method")
+			}
+		}
+	}
+
+	for original, method := range o.methodMap {
+		if strings.TrimSpace(original) == "" || strings.TrimSpace(method) == "" {
+			return errors.New("methodoverride: empty method on method map")
+		}
+	}
+
+	if len(o.getters) == 0 && len(o.methodMap) == 0 {
+		return errors.New("methodoverride: no getters")
+	}
+
+	if o.maxGetters > 0 && len(o.getters) > o.maxGetters {
+		return fmt.Errorf("methodoverride: %d getters registered, more than the maximum of %d", len(o.getters), o.maxGetters)
+	}
+
+	if !o.allowDangerousMethods {
+		for _, method := range o.targetMethods {
+			if isDangerousMethod(method) {
+				return fmt.Errorf("methodoverride: target method %s requires AllowDangerousMethods", method)
+			}
+		}
+	}
+
+	for _, method := range o.methods {
+		if !o.isAllowedMethod(method) {
+			return fmt.Errorf("methodoverride: method %s can be overridden but it is rejected", method)
+		}
+	}
+
+	return nil
+}
+
+func (o *options) resetGetters() {
+	o.getters = o.getters[0:0]
+	o.headers = o.headers[0:0]
+	o.headerKeys = o.headerKeys[0:0]
+	o.formFields = o.formFields[0:0]
+	o.queryParams = o.queryParams[0:0]
+}
+
 func (o *options) canOverride(method string) bool {
 	for _, s := range o.methods {
 		if s == method {
@@ -30,255 +188,3375 @@ func (o *options) canOverride(method string) bool {
 	return false
 }
 
-func (o *options) get(w http.ResponseWriter, r *http.Request) string {
-	for _, getter := range o.getters {
-		if v := getter(w, r); v != "" {
-			return strings.ToUpper(v)
+func (o *options) isAllowedMethod(method string) bool {
+	if len(o.allowedMethods) == 0 {
+		return true
+	}
+
+	for _, s := range o.allowedMethods {
+		if s == method {
+			return true
 		}
 	}
 
-	return ""
+	return false
 }
 
-// Option sets options for a fresh method override wrapper.
-// See `New` package-level function for more.
-type Option func(*options)
+// canOverrideTo reports the reason the request cannot be overridden with the "method",
+// empty if it can.
+func (o *options) canOverrideTo(method string) string {
+	if !isToken(method) {
+		return ReasonInvalidToken
+	}
 
-// Methods can be used to add methods that can be overridden.
-// Defaults to "POST".
-func Methods(methods ...string) Option {
-	for i, s := range methods {
-		methods[i] = strings.ToUpper(s)
+	if o.maxMethodLength > 0 && len(method) > o.maxMethodLength {
+		return ReasonTooLong
 	}
 
-	return func(opts *options) {
-		opts.methods = append(opts.methods, methods...)
+	if !o.allowDangerousMethods && isDangerousMethod(method) {
+		return ReasonDangerousMethod
 	}
-}
 
-// SaveOriginalMethod will save the original method
-// on Request.Context().Value(requestContextKey).
-//
-// Defaults to nil, don't save it.
-func SaveOriginalMethod(requestContextKey interface{}) Option {
-	return func(opts *options) {
-		if requestContextKey == nil {
-			opts.saveOriginalMethodContextKey = nil
+	if o.strictMethods && !o.isKnownMethod(method) {
+		return ReasonNotAllowed
+	}
+
+	if len(o.targetMethods) == 0 {
+		return ""
+	}
+
+	for _, targets := range [][]string{o.targetMethods, o.extensionTargetMethods} {
+		for _, s := range targets {
+			if s == method {
+				return ""
+			}
 		}
-		opts.saveOriginalMethodContextKey = requestContextKey
 	}
+
+	return ReasonNotAllowed
 }
 
-// GetterFunc is the type signature for declaring custom logic
-// to extract the method name which a POST request will be replaced with.
-type GetterFunc func(http.ResponseWriter, *http.Request) string
+// isToken reports whether the "method" is a valid HTTP token, see RFC 7230.
+func isToken(method string) bool {
+	if method == "" {
+		return false
+	}
 
-// Getter sets a custom logic to use to extract the method name
-// to override the POST method with.
-// Defaults to nil.
-func Getter(customFunc GetterFunc) Option {
-	return func(opts *options) {
-		opts.getters = append(opts.getters, customFunc)
+	for i := 0; i < len(method); i++ {
+		c := method[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte("\"(),/:;<=>?@[\\]{}", c) >= 0 {
+			return false
+		}
 	}
+
+	return true
 }
 
-// Headers that client can send to specify a method
-// to override the POST method with.
-//
-// Defaults to:
-// X-HTTP-Method
-// X-HTTP-Method-Override
-// X-Method-Override
-func Headers(headers ...string) Option {
-	getter := func(w http.ResponseWriter, r *http.Request) string {
-		for _, s := range headers {
-			if v := r.Header.Get(s); v != "" {
-				w.Header().Add("Vary", s)
-				return v
-			}
+func (o *options) allow(r *http.Request) bool {
+	for _, condition := range o.conditions {
+		if !condition(r) {
+			return false
 		}
-
-		return ""
 	}
 
-	return Getter(getter)
+	return true
 }
 
-const postMaxMemory = 32 << 20
+// methods are the standard HTTP methods.
+var methods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
 
-// FormField specifies a form field to use to determinate the method
-// to override the POST method with.
-//
-// Example Field:
-// <input type="hidden" name="_method" value="DELETE">
-//
-// Defaults to: "_method".
-func FormField(fieldName string) Option {
-	return Getter(func(w http.ResponseWriter, r *http.Request) string {
-		if form, has := getForm(r, postMaxMemory, true); has {
-			if v := form[fieldName]; len(v) > 0 {
-				return v[0]
+func (o *options) isKnownMethod(method string) bool {
+	for _, known := range [][]string{methods, o.knownMethods, o.targetMethods} {
+		for _, s := range known {
+			if s == method {
+				return true
 			}
 		}
-		return ""
-	})
+	}
+
+	return false
 }
 
-// getForm returns the request form (url queries, post or multipart) values.
-func getForm(r *http.Request, postMaxMemory int64, resetBody bool) (form map[string][]string, found bool) {
-	/*
-		net/http/request.go#1219
-		for k, v := range f.Value {
-			r.Form[k] = append(r.Form[k], v...)
-			// r.PostForm should also be populated. See Issue 9305.
-			r.PostForm[k] = append(r.PostForm[k], v...)
+func (o *options) authorize(r *http.Request, originalMethod, newMethod string) bool {
+	for _, authorize := range o.authorizers {
+		if !authorize(r, originalMethod, newMethod) {
+			return false
 		}
-	*/
+	}
 
-	if form := r.Form; len(form) > 0 {
-		return form, true
+	return true
+}
+
+// match is the result of the getter which the method to override with came from.
+type match struct {
+	source *source // nil if the method is not a getter's one.
+	path   string  // if not empty, the path to rewrite the request path with.
+}
+
+// resolve returns the method to override the "originalMethod" with
+// or empty if the request should not be overridden,
+// along with the getter's result which the method came from.
+func (o *options) resolve(w http.ResponseWriter, r *http.Request, originalMethod string) (string, match) {
+	canOverride := o.canOverride(originalMethod)
+	forcedMethod := o.methodMap[originalMethod]
+	if !canOverride && forcedMethod == "" {
+		return "", match{}
 	}
 
-	if form := r.PostForm; len(form) > 0 {
-		return form, true
+	if (!o.allowReoverride && isOverridden(r)) || !o.allow(r) {
+		return "", match{}
 	}
 
-	if m := r.MultipartForm; m != nil {
-		if len(m.Value) > 0 {
-			return m.Value, true
+	if canOverride {
+		if newMethod, m := o.get(w, r); newMethod != "" {
+			return newMethod, m
 		}
 	}
 
-	var bodyCopy []byte
+	return forcedMethod, match{}
+}
 
-	if resetBody {
-		// on POST, PUT and PATCH it will read the form values from request body otherwise from URL queries.
-		if m := r.Method; m == "POST" || m == "PUT" || m == "PATCH" {
-			bodyCopy, _ = getBody(r, resetBody)
-			if len(bodyCopy) == 0 {
-				return nil, false
-			}
-			// r.Body = ioutil.NopCloser(io.TeeReader(r.Body, buf))
-		} else {
-			resetBody = false
+func (o *options) isTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range o.trustedProxies {
+		if network.Contains(ip) {
+			return true
 		}
 	}
 
-	// ParseMultipartForm calls `request.ParseForm` automatically
-	// therefore we don't need to call it here, although it doesn't hurt.
-	// After one call to ParseMultipartForm or ParseForm,
-	// subsequent calls have no effect, are idempotent.
-	err := r.ParseMultipartForm(postMaxMemory)
-	if resetBody {
-		r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyCopy))
+	return false
+}
+
+// upperMethod returns the uppercase "method",
+// the common already uppercase ASCII method is returned as it is, without an allocation.
+func upperMethod(method string) string {
+	for i := 0; i < len(method); i++ {
+		if c := method[i]; c >= utf8.RuneSelf || ('a' <= c && c <= 'z') {
+			return strings.ToUpper(method)
+		}
 	}
-	if err != nil && err != http.ErrNotMultipart {
-		return nil, false
+
+	return method
+}
+
+// untrusted reports whether the getters which read the request headers
+// must be skipped for the request "r", see `TrustedProxies`.
+func (o *options) untrusted(r *http.Request) bool {
+	return len(o.trustedProxies) > 0 && !o.isTrustedProxy(r)
+}
+
+func (o *options) stopsAfter(kind SourceKind) bool {
+	for _, k := range o.stopAfterSources {
+		if k == kind {
+			return true
+		}
 	}
 
-	if form := r.Form; len(form) > 0 {
-		return form, true
+	return false
+}
+
+func (o *options) get(w http.ResponseWriter, r *http.Request) (string, match) {
+	if o.varyAllHeaders {
+		for _, key := range o.headerKeys {
+			w.Header().Add("Vary", key)
+		}
 	}
 
-	if form := r.PostForm; len(form) > 0 {
-		return form, true
+	var results []chan getterResult
+	if o.concurrentGetters {
+		var wg sync.WaitGroup
+		results = o.startCustomGetters(w, r, &wg)
+		// the getters must not use the request and the response writer
+		// after the handler returns.
+		defer wg.Wait()
 	}
 
-	if m := r.MultipartForm; m != nil {
-		if len(m.Value) > 0 {
-			return m.Value, true
+	var trace []string
+	untrusted := o.untrusted(r)
+	for i, getter := range o.getters {
+		if untrusted && getter.readsHeaders {
+			if o.diagnosticsHeader != "" {
+				trace = append(trace, getter.kind.String()+":untrusted")
+			}
+			continue
+		}
+
+		var (
+			v, path string
+			present bool
+		)
+		if results != nil && results[i] != nil {
+			res := <-results[i]
+			v, present = res.value, res.present
+		} else {
+			v, path, present = getter.get(w, r)
+		}
+		if v != "" {
+			return upperMethod(v), match{source: &o.getters[i], path: path}
+		}
+
+		if o.diagnosticsHeader != "" {
+			status := "absent"
+			if present {
+				status = "empty"
+			}
+			trace = append(trace, getter.kind.String()+":"+status)
+		}
+
+		if present && (o.stopOnEmpty || o.stopsAfter(getter.kind)) {
+			break
 		}
 	}
 
-	return nil, false
+	if o.diagnosticsHeader != "" {
+		w.Header().Set(o.diagnosticsHeader, strings.Join(trace, ";"))
+	}
+
+	return "", match{}
 }
 
-// getBody reads and returns the request body.
-func getBody(r *http.Request, resetBody bool) ([]byte, error) {
-	data, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
-	}
+type getterResult struct {
+	value   string
+	present bool
+}
 
-	if resetBody {
-		// * remember, Request.Body has no Bytes(), we have to consume them first
-		// and after re-set them to the body, this is the only solution.
-		r.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+// startCustomGetters runs the custom getters, see `Getter2`, in goroutines tracked by "wg",
+// the returned results are indexed by getter, nil for the builtin ones.
+func (o *options) startCustomGetters(w http.ResponseWriter, r *http.Request, wg *sync.WaitGroup) []chan getterResult {
+	var results []chan getterResult
+	for i, getter := range o.getters {
+		if !getter.concurrent {
+			continue
+		}
+
+		if results == nil {
+			results = make([]chan getterResult, len(o.getters))
+		}
+
+		// buffered, the goroutine exits even if the result is not consumed.
+		result := make(chan getterResult, 1)
+		results[i] = result
+		wg.Add(1)
+		go func(get sourceFunc) {
+			defer wg.Done()
+			v, _, present := get(w, r)
+			result <- getterResult{value: v, present: present}
+		}(getter.get)
 	}
 
-	return data, nil
+	return results
 }
 
-// Query specifies a url parameter name to use to determinate the method
-// to override the POST methos with.
-//
-// Example URL Query string:
-// http://localhost:8080/path?_method=DELETE
+// Option sets options for a fresh method override wrapper.
+// See `New` package-level function for more.
+type Option func(*options)
+
+// NormalizeMethod sets a function which normalizes the request method
+// before it is compared against the methods that can be overridden
+// and the other method options, e.g. to handle unusual client inputs.
+// The normalized method is the original method saved by `SaveOriginalMethod`,
+// the request method itself is only changed on override.
 //
-// Defaults to: "_method".
-func Query(paramName string) Option {
-	getter := func(w http.ResponseWriter, r *http.Request) string {
-		return r.URL.Query().Get(paramName)
+// Defaults to trimming the surrounding whitespace and uppercasing the method.
+func NormalizeMethod(normalize func(method string) string) Option {
+	return func(opts *options) {
+		opts.normalizeMethod = normalize
+	}
+}
+
+// Methods can be used to add methods that can be overridden.
+// Defaults to "POST".
+func Methods(methods ...string) Option {
+	for i, s := range methods {
+		methods[i] = strings.ToUpper(s)
 	}
 
-	return Getter(getter)
+	return func(opts *options) {
+		opts.methods = append(opts.methods, methods...)
+	}
 }
 
-// Only clears all default or previously registered values
-// and uses only the "o" option(s).
+// RejectMethodsNotIn rejects the requests which their original method
+// is not one of the given "methods" with a 405 Method Not Allowed status code,
+// before any override attempt.
 //
-// The default behavior is to check for all the following by order:
-// headers, form field, query string
-// and any custom getter (if set).
-// Use this method to override that
-// behavior and use only the passed option(s)
-// to determinate the method to override with.
+// Defaults to empty, all request methods are accepted.
+func RejectMethodsNotIn(methods ...string) Option {
+	for i, s := range methods {
+		methods[i] = strings.ToUpper(s)
+	}
+
+	return func(opts *options) {
+		opts.allowedMethods = append(opts.allowedMethods, methods...)
+	}
+}
+
+// MethodMap sets fixed method rewrites, the key is the original method
+// and the value is the method to override it with.
+// The mapped method is used when no getter yields a method,
+// the original method does not have to be registered through `Methods`.
 //
-// Use cases:
+// Example Code:
 //
-//  1. When need to check only for headers and ignore other fields:
-//     New(Only(Headers("X-Custom-Header")))
+//	MethodMap(map[string]string{http.MethodPut: http.MethodPatch})
+func MethodMap(methods map[string]string) Option {
+	return func(opts *options) {
+		if opts.methodMap == nil {
+			opts.methodMap = make(map[string]string, len(methods))
+		}
+
+		for original, method := range methods {
+			opts.methodMap[strings.ToUpper(original)] = strings.ToUpper(method)
+		}
+	}
+}
+
+// AllowedTargetMethods adds methods that can be used to override with.
+// When at least one is registered, any other resolved method is ignored.
 //
-//  2. When need to check only for (first) form field and (second) custom getter:
-//     New(Only(FormField("fieldName"), Getter(...)))
-func Only(o ...Option) Option {
+// Defaults to empty, any method can be used.
+func AllowedTargetMethods(methods ...string) Option {
+	for i, s := range methods {
+		methods[i] = strings.ToUpper(s)
+	}
+
 	return func(opts *options) {
-		opts.getters = opts.getters[0:0]
-		opts.configure(o...)
+		opts.targetMethods = append(opts.targetMethods, methods...)
 	}
 }
 
-// New returns a new method override wrapper
-// which can be registered on any HTTP server.
+// IdempotentOnly allows overriding only with idempotent methods,
+// any other resolved method is ignored.
+// See `AllowedTargetMethods` too.
 //
-// Use this wrapper when you expecting clients
-// that do not support certain HTTP operations such as DELETE or PUT for security reasons.
-// This wrapper will accept a method, based on criteria, to override the POST method with.
-func New(opt ...Option) func(next http.Handler) http.Handler {
-	opts := new(options)
-	// Default values.
-	opts.configure(
-		Methods(http.MethodPost),
+// Defaults to the "GET", "HEAD", "PUT", "DELETE" and "OPTIONS" methods
+// when no methods are passed.
+func IdempotentOnly(methods ...string) Option {
+	if len(methods) == 0 {
+		methods = []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodPut,
+			http.MethodDelete,
+			http.MethodOptions,
+		}
+	}
+
+	return AllowedTargetMethods(methods...)
+}
+
+// WebDAVMethods registers the WebDAV methods:
+// "PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK" and "UNLOCK",
+// as known methods, see `StrictMethods`.
+// It does not restrict the methods to override with,
+// but when an `AllowedTargetMethods` whitelist is registered, they are allowed too.
+//
+// Example Code:
+//
+//	New(WebDAVMethods(), AllowedTargetMethods(http.MethodPut, http.MethodDelete))
+func WebDAVMethods() Option {
+	return extensionMethods("PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK")
+}
+
+// CacheMethods registers the cache invalidation methods
+// of CDNs and caching proxies: "PURGE", "BAN" and "REFRESH",
+// as known methods, see `StrictMethods`.
+// It does not restrict the methods to override with,
+// but when an `AllowedTargetMethods` whitelist is registered, they are allowed too.
+//
+// Example Code:
+//
+//	New(CacheMethods(), StrictMethods())
+func CacheMethods() Option {
+	return extensionMethods("PURGE", "BAN", "REFRESH")
+}
+
+func extensionMethods(methods ...string) Option {
+	return func(opts *options) {
+		opts.knownMethods = append(opts.knownMethods, methods...)
+		opts.extensionTargetMethods = append(opts.extensionTargetMethods, methods...)
+	}
+}
+
+// SaveOriginalMethod will save the original method
+// on Request.Context().Value(requestContextKey).
+//
+// Defaults to nil, don't save it.
+func SaveOriginalMethod(requestContextKey interface{}) Option {
+	return func(opts *options) {
+		if requestContextKey == nil {
+			opts.saveOriginalMethodContextKey = nil
+		}
+		opts.saveOriginalMethodContextKey = requestContextKey
+	}
+}
+
+// SaveResolvedMethod will save the final method,
+// overridden or native, on Request.Context().Value(requestContextKey),
+// e.g. for logging middlewares. Use it along with `SaveOriginalMethod`
+// to get both the method before and after the override.
+//
+// Defaults to nil, don't save it.
+func SaveResolvedMethod(requestContextKey interface{}) Option {
+	return func(opts *options) {
+		opts.saveResolvedMethodContextKey = requestContextKey
+	}
+}
+
+// SaveOverrideSource will save the source of the method to override with,
+// the source kind along with the matched name, e.g. "header:X-HTTP-Method",
+// "form:_method" or "query:_method", on Request.Context().Value(requestContextKey)
+// when an override happens, e.g. for audit logging.
+// Use `OverrideSource` to get it.
+// The sources without a name are saved as their kind only, e.g. "custom",
+// a method forced by `MethodMap` is not saved.
+//
+// Defaults to nil, don't save it.
+func SaveOverrideSource(requestContextKey interface{}) Option {
+	return func(opts *options) {
+		opts.saveOverrideSourceContextKey = requestContextKey
+	}
+}
+
+// OverrideSource returns the source of the method the request was overridden with,
+// see `SaveOverrideSource`.
+func OverrideSource(r *http.Request, requestContextKey interface{}) (string, bool) {
+	src, ok := r.Context().Value(requestContextKey).(string)
+	return src, ok
+}
+
+// MarkOverridden will save true
+// on Request.Context().Value(requestContextKey) when an override happens.
+// Use `WasOverridden` to check it.
+//
+// Defaults to nil, don't mark it.
+func MarkOverridden(requestContextKey interface{}) Option {
+	return func(opts *options) {
+		opts.markOverriddenContextKey = requestContextKey
+	}
+}
+
+// WasOverridden reports whether the request method was overridden,
+// see `MarkOverridden`. A native request method is not marked.
+func WasOverridden(r *http.Request, requestContextKey interface{}) bool {
+	overridden, _ := r.Context().Value(requestContextKey).(bool)
+	return overridden
+}
+
+type corsRequestMethodContextKey struct{}
+
+// CORSRequestMethod saves the method of the "Access-Control-Request-Method" header
+// of the CORS preflight (OPTIONS) requests on the request context,
+// see `CORSRequestMethodFromContext`,
+// so the handlers know the intended real method during the preflight.
+// The preflight request method itself is never overridden.
+//
+// Defaults to false.
+func CORSRequestMethod() Option {
+	return func(opts *options) {
+		opts.corsRequestMethod = true
+	}
+}
+
+// CORSRequestMethodFromContext returns the method requested by a CORS preflight request,
+// see `CORSRequestMethod`.
+func CORSRequestMethodFromContext(r *http.Request) (string, bool) {
+	method, ok := r.Context().Value(corsRequestMethodContextKey{}).(string)
+	return method, ok
+}
+
+// SaveOriginalMethodHeader will save the original method
+// on the "name" request header, e.g. "X-Original-Method: POST",
+// for proxies and access logs which capture the request headers.
+// The header is not set when no override happens and
+// a value of the same header sent by the client is removed.
+// It can be used along with `SaveOriginalMethod`.
+//
+// Defaults to empty, don't save it.
+func SaveOriginalMethodHeader(name string) Option {
+	return func(opts *options) {
+		opts.saveOriginalMethodHeader = textproto.CanonicalMIMEHeaderKey(name)
+	}
+}
+
+// StrictMethods allows overriding only with known methods,
+// any other resolved method, e.g. a typo like "DELET", is ignored.
+//
+// The known methods are the standard HTTP methods:
+// "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS" and "TRACE",
+// the methods registered through `AllowedTargetMethods`
+// and the extra "methods" passed here.
+func StrictMethods(methods ...string) Option {
+	for i, s := range methods {
+		methods[i] = strings.ToUpper(s)
+	}
+
+	return func(opts *options) {
+		opts.strictMethods = true
+		opts.knownMethods = append(opts.knownMethods, methods...)
+	}
+}
+
+// FallbackMethod sets a method to override with
+// when the resolved method is not a known one, see `StrictMethods`,
+// instead of ignoring it.
+//
+// Defaults to empty, unknown methods are ignored when `StrictMethods` is used.
+func FallbackMethod(method string) Option {
+	return func(opts *options) {
+		opts.fallbackMethod = strings.ToUpper(method)
+	}
+}
+
+// NormalizeHeadToGet overrides with the "GET" method
+// when the resolved method is "HEAD", for handlers registered only for "GET".
+// The "HEAD" method should still be accepted by the `AllowedTargetMethods`
+// and `Authorize` options, if any.
+//
+// Note that, unlike a native "HEAD" request, the response body
+// written by the "GET" handler is sent to the client,
+// the original request was not a "HEAD" one.
+//
+// Defaults to false.
+func NormalizeHeadToGet() Option {
+	return func(opts *options) {
+		opts.normalizeHeadToGet = true
+	}
+}
+
+// AllowDangerousMethods allows overriding with the "TRACE" and "CONNECT" methods.
+//
+// Defaults to false, these methods are ignored.
+func AllowDangerousMethods() Option {
+	return func(opts *options) {
+		opts.allowDangerousMethods = true
+	}
+}
+
+func isDangerousMethod(method string) bool {
+	return method == http.MethodTrace || method == http.MethodConnect
+}
+
+// MaxMethodLength ignores the methods to override with
+// which are longer than "n" characters.
+// Along with the token validation it bounds the values
+// which can be assigned to the request method.
+// A zero or negative "n" disables the limit.
+//
+// Defaults to 32.
+func MaxMethodLength(n int) Option {
+	return func(opts *options) {
+		opts.maxMethodLength = n
+	}
+}
+
+// ClearBodyForBodyless clears the request body
+// when the method to override with conventionally has no body,
+// that is "GET", "HEAD" and "DELETE".
+// Use it when the next handlers misbehave on such requests with a body.
+//
+// Defaults to false.
+func ClearBodyForBodyless() Option {
+	return func(opts *options) {
+		opts.clearBodyForBodyless = true
+	}
+}
+
+// ClearFormContentType removes the "Content-Type" request header
+// when the method to override with came from a form getter, e.g. `FormField`,
+// and it conventionally has no body, that is "GET", "HEAD" and "DELETE".
+// Use it when the next handlers misbehave on such requests
+// with a form content type, e.g. JSON APIs behind some gateways.
+//
+// Defaults to false.
+func ClearFormContentType() Option {
+	return func(opts *options) {
+		opts.clearFormContentType = true
+	}
+}
+
+func isBodylessMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodDelete
+}
+
+// EchoHeader sets a response header which documents the applied override,
+// e.g. "X-Method-Override-Applied: POST->DELETE".
+// The header is not set when no override happens.
+// Useful for client-side debugging.
+//
+// Defaults to empty, no header is set.
+func EchoHeader(name string) Option {
+	return func(opts *options) {
+		opts.echoHeader = name
+	}
+}
+
+// AllowReoverride allows a request to be overridden again
+// by another method override wrapper of the same handlers chain.
+//
+// Defaults to false, an already overridden request is left as it is,
+// so accidentally double-wrapping a handler overrides its requests once.
+func AllowReoverride() Option {
+	return func(opts *options) {
+		opts.allowReoverride = true
+	}
+}
+
+// overriddenContextKey is the request context key
+// which marks an already overridden request.
+type overriddenContextKey struct{}
+
+func isOverridden(r *http.Request) bool {
+	return r.Context().Value(overriddenContextKey{}) != nil
+}
+
+// Enabled sets a function which reports whether the method override
+// is enabled for a request, e.g. backed by a feature flag service.
+// When it returns false the request is passed through as it is:
+// no option is applied and the request body is not read.
+//
+// Example Code:
+//
+//	Enabled(func(r *http.Request) bool { return flags.IsOn("method-override") })
+//
+// Defaults to nil, always enabled.
+func Enabled(enabled func(r *http.Request) bool) Option {
+	return func(opts *options) {
+		opts.enabled = enabled
+	}
+}
+
+// RequireSecret allows the method override only when
+// the request carries a "headerName" header with the "secret" value,
+// otherwise the request method is left as it is.
+// The values are compared in constant time.
+//
+// Use it to let internal services use the method override
+// while ignoring any client-supplied override fields.
+// An empty "secret", e.g. read from an unset environment variable,
+// never matches and it is reported by `NewStrict`.
+func RequireSecret(headerName, secret string) Option {
+	condition := func(r *http.Request) bool {
+		if secret == "" {
+			return false
+		}
+
+		v := r.Header.Get(headerName)
+		return subtle.ConstantTimeCompare([]byte(v), []byte(secret)) == 1
+	}
+
+	return func(opts *options) {
+		if secret == "" {
+			opts.errs = append(opts.errs, fmt.Errorf("methodoverride: empty secret for header %s", headerName))
+		}
+
+		opts.conditions = append(opts.conditions, condition)
+	}
+}
+
+// The reasons of an `OverrideError`.
+const (
+	// ReasonInvalidToken is reported when the method is not a valid HTTP token.
+	ReasonInvalidToken = "invalid-token"
+	// ReasonNotAllowed is reported when the method is not accepted by the
+	// `StrictMethods`, `AllowedTargetMethods` or `Authorize` options.
+	ReasonNotAllowed = "not-allowed"
+	// ReasonDangerousMethod is reported when the method is a dangerous one,
+	// see `AllowDangerousMethods`.
+	ReasonDangerousMethod = "dangerous-method"
+	// ReasonThrottled is reported when the client exceeded its overrides limit,
+	// see `ThrottleOverrides`.
+	ReasonThrottled = "throttled"
+	// ReasonTooLong is reported when the method is longer than the maximum length,
+	// see `MaxMethodLength`.
+	ReasonTooLong = "too-long"
+)
+
+// OverrideError describes a rejected override attempt, see `OnReject`.
+type OverrideError struct {
+	// Original is the request method.
+	Original string
+	// Attempted is the method the request was attempted to be overridden with.
+	Attempted string
+	// Reason is the reason of the rejection, e.g. `ReasonNotAllowed`.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *OverrideError) Error() string {
+	return fmt.Sprintf("methodoverride: override of %s with %q rejected: %s", e.Original, e.Attempted, e.Reason)
+}
+
+// OnReject registers a handler which is notified when an override attempt is rejected,
+// the request is still served with its original method.
+//
+// Example Code:
+//
+//	OnReject(func(err *OverrideError) {
+//		log.Println(err)
+//	})
+//
+// Defaults to nil, rejections are silently ignored.
+func OnReject(handler func(err *OverrideError)) Option {
+	return func(opts *options) {
+		opts.rejectHandler = handler
+	}
+}
+
+// OverrideEvent describes an override, see `OnOverride`.
+type OverrideEvent struct {
+	// Request is the overridden request,
+	// on dry run it is the request as it was received.
+	Request *http.Request
+	// Original is the request method.
+	Original string
+	// Method is the method the request was overridden with.
+	Method string
+	// DryRun reports whether the override was not applied, see `DryRun`.
+	DryRun bool
+}
+
+// OnOverride registers a handler which is notified when an override happens.
+//
+// Example Code:
+//
+//	OnOverride(func(e *OverrideEvent) {
+//		log.Printf("%s %s -> %s (dry run: %v)", e.Request.URL, e.Original, e.Method, e.DryRun)
+//	})
+//
+// Defaults to nil.
+func OnOverride(handler func(event *OverrideEvent)) Option {
+	return func(opts *options) {
+		opts.overrideHandler = handler
+	}
+}
+
+// DryRun resolves the method to override with as usual
+// but it does not apply it, the request is served with its original method.
+// The `OnOverride` handler is still notified, with the DryRun field set,
+// e.g. to validate a configuration against production traffic.
+// Note that the getters may still read and reset the request body
+// and the `RouteChecker` is not consulted.
+//
+// Defaults to false.
+func DryRun() Option {
+	return func(opts *options) {
+		opts.dryRun = true
+	}
+}
+
+// RouteCheckerFunc is the type signature for declaring custom logic
+// to report whether the route of the request accepts the "method"
+// and, if not, the methods it accepts, see `RouteChecker`.
+type RouteCheckerFunc func(r *http.Request, method string) (allowed bool, allow []string)
+
+// RouteChecker registers a hook which is consulted with the method to override with,
+// e.g. against the route table of a router.
+// If the route does not accept the method then the request is not served,
+// a 405 Method Not Allowed status code is sent along with the "Allow" header instead.
+//
+// Example Code:
+//
+//	RouteChecker(func(r *http.Request, method string) (bool, []string) {
+//		allow := routes[r.URL.Path]
+//		for _, m := range allow {
+//			if m == method {
+//				return true, nil
+//			}
+//		}
+//		return false, allow
+//	})
+//
+// Defaults to nil, the request is passed through.
+func RouteChecker(checker RouteCheckerFunc) Option {
+	return func(opts *options) {
+		opts.routeChecker = checker
+	}
+}
+
+// AuthorizeFunc is the type signature for declaring custom logic
+// to approve or deny the override of the "original" method with the "target" one.
+type AuthorizeFunc func(r *http.Request, original, target string) bool
+
+// Authorize registers a hook which can veto an override decision,
+// when it returns false the request method is left as it is.
+// Multiple hooks should all approve the override.
+//
+// Example Code:
+//
+//	Authorize(func(r *http.Request, original, target string) bool {
+//	    return target != http.MethodDelete || isAdmin(r)
+//	})
+func Authorize(authorizeFunc AuthorizeFunc) Option {
+	return func(opts *options) {
+		opts.authorizers = append(opts.authorizers, authorizeFunc)
+	}
+}
+
+// ThrottleOverrides allows at most "max" overrides per client every "per" duration,
+// any other override attempt of that client is skipped,
+// the request is served with its original method. See `OnReject` too.
+// Only the applied overrides count against the limit,
+// not the ones logged by `DryRun` or rejected by the `RouteChecker`.
+// The clients are identified by the "keyFunc",
+// if nil, by the host of the request's remote address.
+// Use it to mitigate the abuse of e.g. DELETE through method override.
+// `NewStrict` reports a non-positive "max" or "per".
+//
+// Example Code:
+//
+//	ThrottleOverrides(10, time.Minute, func(r *http.Request) string {
+//		return r.Header.Get("X-API-Key")
+//	})
+func ThrottleOverrides(max int, per time.Duration, keyFunc func(r *http.Request) string) Option {
+	return func(opts *options) {
+		if max <= 0 {
+			opts.errs = append(opts.errs, fmt.Errorf("methodoverride: invalid throttle max %d", max))
+		}
+
+		if per <= 0 {
+			opts.errs = append(opts.errs, fmt.Errorf("methodoverride: invalid throttle duration %s", per))
+		}
+
+		opts.throttle = newThrottle(max, per, keyFunc)
+	}
+}
+
+// RequireIdempotencyKeyFor allows the method override with one of the given "methods"
+// only when the request contains an "Idempotency-Key" header,
+// so clients are nudged toward safe retries of the mutating requests.
+// Other methods are unaffected.
+//
+// Defaults to the "PUT", "PATCH" and "DELETE" methods when no "methods" are given.
+func RequireIdempotencyKeyFor(methods ...string) Option {
+	if len(methods) == 0 {
+		methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	for i, s := range methods {
+		methods[i] = strings.ToUpper(s)
+	}
+
+	return Authorize(func(r *http.Request, originalMethod, newMethod string) bool {
+		for _, method := range methods {
+			if method == newMethod {
+				return r.Header.Get("Idempotency-Key") != ""
+			}
+		}
+
+		return true
+	})
+}
+
+// RequireContentType allows the method override only when
+// the request's Content-Type media type, parameters are ignored,
+// is one of the given "types", otherwise the request method is left as it is.
+// Use it to avoid accidental overrides on JSON or binary requests.
+//
+// Example Code:
+//
+//	RequireContentType("application/x-www-form-urlencoded", "multipart/form-data")
+func RequireContentType(types ...string) Option {
+	for i, s := range types {
+		types[i] = strings.ToLower(s)
+	}
+
+	condition := func(r *http.Request) bool {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+
+		for _, s := range types {
+			if s == mediaType {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(opts *options) {
+		opts.conditions = append(opts.conditions, condition)
+	}
+}
+
+// SameSiteOnly allows the method override only when the request's Origin header,
+// or the Referer header if the Origin is missing, host matches the request's host,
+// so a forged form of another site cannot override the method.
+// Requests without both headers, e.g. sent by non-browser clients, are allowed.
+// See `SameOriginOnly` to compare the schemes too.
+func SameSiteOnly() Option {
+	return sameSite(false)
+}
+
+// SameOriginOnly same as `SameSiteOnly` but the scheme of the Origin or Referer
+// should match the request's one too, "https" when served over TLS, otherwise "http".
+func SameOriginOnly() Option {
+	return sameSite(true)
+}
+
+func sameSite(compareScheme bool) Option {
+	return func(opts *options) {
+		opts.conditions = append(opts.conditions, func(r *http.Request) bool {
+			source := r.Header.Get("Origin")
+			if source == "" {
+				if source = r.Header.Get("Referer"); source == "" {
+					return true
+				}
+			}
+
+			u, err := url.Parse(source)
+			if err != nil || !strings.EqualFold(u.Host, r.Host) {
+				return false // including "Origin: null".
+			}
+
+			if compareScheme {
+				scheme := "http"
+				if r.TLS != nil {
+					scheme = "https"
+				}
+
+				return strings.EqualFold(u.Scheme, scheme)
+			}
+
+			return true
+		})
+	}
+}
+
+// HTTPSOnly allows the method override only for the requests served over TLS
+// or forwarded by a proxy which received them over https,
+// so the method override is not honored on insecure connections.
+// The "protoHeaders" are the headers of the proxy's scheme,
+// defaults to "X-Forwarded-Proto", an empty name disables them.
+// They are honored only when the `TrustedProxies` option is set
+// and the request comes from one of the trusted proxies,
+// otherwise any client could claim https.
+//
+// Example Code:
+//
+//	HTTPSOnly()                   // TLS or X-Forwarded-Proto: https of a trusted proxy.
+//	HTTPSOnly("X-Forwarded-Scheme")
+//	HTTPSOnly("")                 // TLS only.
+func HTTPSOnly(protoHeaders ...string) Option {
+	if len(protoHeaders) == 0 {
+		protoHeaders = []string{"X-Forwarded-Proto"}
+	}
+
+	var keys []string
+	for _, name := range protoHeaders {
+		if name != "" {
+			keys = append(keys, textproto.CanonicalMIMEHeaderKey(name))
+		}
+	}
+
+	return func(opts *options) {
+		opts.conditions = append(opts.conditions, func(r *http.Request) bool {
+			if r.TLS != nil {
+				return true
+			}
+
+			if len(opts.trustedProxies) == 0 || !opts.isTrustedProxy(r) {
+				return false
+			}
+
+			for _, key := range keys {
+				if strings.EqualFold(strings.TrimSpace(r.Header.Get(key)), "https") {
+					return true
+				}
+			}
+
+			return false
+		})
+	}
+}
+
+// SkipBrowsers skips the method override for the requests sent by browsers,
+// which can use the real methods through fetch,
+// so the method override is left for the constrained clients.
+// A request is considered to be sent by a browser if it contains
+// any of the "Sec-Fetch-Site", "Sec-Fetch-Mode" and "Sec-Fetch-Dest" headers.
+// As this is a heuristic, old browsers do not send them,
+// use `SkipIfUserAgent` for a custom matcher.
+func SkipBrowsers() Option {
+	return func(opts *options) {
+		opts.conditions = append(opts.conditions, func(r *http.Request) bool {
+			for _, key := range []string{"Sec-Fetch-Site", "Sec-Fetch-Mode", "Sec-Fetch-Dest"} {
+				if len(r.Header[key]) > 0 {
+					return false
+				}
+			}
+
+			return true
+		})
+	}
+}
+
+// SkipUpgrades skips the method override for the protocol upgrade requests,
+// e.g. the WebSocket handshakes, which their "Connection" header
+// contains the "upgrade" token and they contain an "Upgrade" header.
+// It prevents breaking real-time endpoints
+// when the `Methods` are misconfigured to include GET.
+func SkipUpgrades() Option {
+	return func(opts *options) {
+		opts.conditions = append(opts.conditions, func(r *http.Request) bool {
+			if r.Header.Get("Upgrade") == "" {
+				return true
+			}
+
+			for _, value := range r.Header["Connection"] {
+				for _, token := range strings.Split(value, ",") {
+					if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+						return false
+					}
+				}
+			}
+
+			return true
+		})
+	}
+}
+
+// SkipIfUserAgent skips the method override for the requests
+// which their User-Agent header is accepted by the "match" function.
+//
+// Example Code:
+//
+//	SkipIfUserAgent(func(ua string) bool { return strings.Contains(ua, "Mozilla/") })
+func SkipIfUserAgent(match func(userAgent string) bool) Option {
+	return func(opts *options) {
+		opts.conditions = append(opts.conditions, func(r *http.Request) bool {
+			return !match(r.UserAgent())
+		})
+	}
+}
+
+// GetterFunc is the type signature for declaring custom logic
+// to extract the method name which a POST request will be replaced with.
+type GetterFunc func(http.ResponseWriter, *http.Request) string
+
+// Getter sets a custom logic to use to extract the method name
+// to override the POST method with.
+// Defaults to nil.
+func Getter(customFunc GetterFunc) Option {
+	return Getter2(func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		v := customFunc(w, r)
+		return v, v != ""
+	})
+}
+
+// GetterFunc2 is like `GetterFunc` but it also reports
+// whether the source of the method was present at all,
+// e.g. a form field which exists but it is empty.
+type GetterFunc2 func(http.ResponseWriter, *http.Request) (value string, present bool)
+
+// GetterWithPriority same as `Getter` but the getter is consulted
+// before the getters of a lower "priority" and after the ones of a higher "priority",
+// no matter the registration order.
+// Getters of the same priority keep their registration order.
+// All other getters have a priority of 0.
+//
+// Example Code:
+//
+//	GetterWithPriority(myGetter, 10) // consulted before the default getters.
+func GetterWithPriority(customFunc GetterFunc, priority int) Option {
+	return func(opts *options) {
+		Getter(customFunc)(opts)
+		opts.getters[len(opts.getters)-1].priority = priority
+	}
+}
+
+// sortGetters sorts the getters by priority, see `GetterWithPriority`.
+func (o *options) sortGetters() {
+	for _, getter := range o.getters {
+		if getter.priority != 0 {
+			sort.SliceStable(o.getters, func(i, j int) bool {
+				return o.getters[i].priority > o.getters[j].priority
+			})
+			return
+		}
+	}
+}
+
+// Getter2 same as `Getter` but it accepts a `GetterFunc2`,
+// so a present but empty source can be reported, see `StopOnEmpty`.
+func Getter2(customFunc GetterFunc2) Option {
+	return func(opts *options) {
+		sourceGetter(SourceCustom, customFunc)(opts)
+		opts.getters[len(opts.getters)-1].concurrent = true
+	}
+}
+
+// RichGetterFunc is the type signature for declaring custom logic
+// to extract both the method name which a POST request will be replaced with
+// and the path to rewrite the request path with, see `RichGetter`.
+type RichGetterFunc func(http.ResponseWriter, *http.Request) (method, newPath string)
+
+// RichGetter same as `Getter` but the custom logic can also report a new path,
+// so clients can tunnel both the method and the path in a POST envelope.
+// If the method and the "newPath" are not empty
+// then the request path is replaced with the "newPath", once the override is applied.
+// Unlike the `Getter`, it always runs sequentially, see `ConcurrentGetters`.
+//
+// Example Code:
+//
+//	RichGetter(func(w http.ResponseWriter, r *http.Request) (string, string) {
+//		return r.Header.Get("X-Envelope-Method"), r.Header.Get("X-Envelope-Path")
+//	})
+func RichGetter(customFunc RichGetterFunc) Option {
+	return pathSourceGetter(SourceCustom, func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+		method, newPath := customFunc(w, r)
+		if method == "" {
+			return "", "", false
+		}
+
+		return method, newPath, true
+	})
+}
+
+// SourceKind is the kind of source a getter extracts the method from.
+type SourceKind int
+
+// The available source kinds.
+const (
+	SourceCustom    SourceKind = iota // a custom getter, e.g. registered by `Getter`.
+	SourceHeader                      // see `Headers`.
+	SourceForm                        // see `FormField` and `FormFields`.
+	SourceQuery                       // see `Query`.
+	SourceMatrix                      // see `MatrixParam`.
+	SourceTrailer                     // see `Trailer`.
+	SourceContext                     // see `ContextGetter`.
+	SourceAccept                      // see `AcceptParam`.
+	SourcePath                        // see `PathPrefixVerb`.
+	SourcePrefer                      // see `PreferParam`.
+	SourceJSON                        // see `JSONField` and `JSONPath`.
+	SourceAgreement                   // see `RequireAgreement` and `AllOf`.
+	SourceRule                        // see `AddRule`.
+	SourceServerVar                   // see `ServerVar`.
+	SourceGroup                       // see `AnyOf`.
+)
+
+var sourceKindNames = map[SourceKind]string{
+	SourceCustom:    "custom",
+	SourceHeader:    "header",
+	SourceForm:      "form",
+	SourceQuery:     "query",
+	SourceMatrix:    "matrix",
+	SourceTrailer:   "trailer",
+	SourceContext:   "context",
+	SourceAccept:    "accept",
+	SourcePath:      "path",
+	SourcePrefer:    "prefer",
+	SourceJSON:      "json",
+	SourceAgreement: "agreement",
+	SourceRule:      "rule",
+	SourceServerVar: "server-var",
+	SourceGroup:     "group",
+}
+
+// String returns the name of the source kind, e.g. "header".
+func (k SourceKind) String() string {
+	if name, ok := sourceKindNames[k]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// MarshalText encodes the source kind as its name, e.g. "header".
+func (k SourceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// sourceFunc is the getter of a source, it reports the method like a `GetterFunc2`
+// and, optionally, a path to rewrite the request path with
+// once the override is applied.
+type sourceFunc func(w http.ResponseWriter, r *http.Request) (value, path string, present bool)
+
+// source is a getter of the chain along with its kind.
+type source struct {
+	kind       SourceKind
+	get        sourceFunc
+	name       func(r *http.Request) string // if not nil, it reports the name of the matched field, see `SaveOverrideSource`.
+	priority   int                          // see `GetterWithPriority`.
+	concurrent bool                         // if true, it can run in a goroutine, see `ConcurrentGetters`.
+	// if true, it reads the request headers and it is skipped
+	// for the requests of untrusted addresses, see `TrustedProxies`.
+	readsHeaders bool
+}
+
+// String returns the kind of the source along with
+// the name of the field matched on the request "r", e.g. "header:X-HTTP-Method".
+func (s *source) String(r *http.Request) string {
+	if s.name != nil {
+		if name := s.name(r); name != "" {
+			return s.kind.String() + ":" + name
+		}
+	}
+
+	return s.kind.String()
+}
+
+func sourceGetter(kind SourceKind, getterFunc GetterFunc2) Option {
+	return namedSourceGetter(kind, getterFunc, nil)
+}
+
+func namedSourceGetter(kind SourceKind, getterFunc GetterFunc2, name func(r *http.Request) string) Option {
+	get := func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+		v, present := getterFunc(w, r)
+		return v, "", present
+	}
+
+	return func(opts *options) {
+		opts.getters = append(opts.getters, source{kind: kind, get: get, name: name})
+	}
+}
+
+// headerSourceGetter same as `namedSourceGetter` but the getter
+// reads the request headers, see `TrustedProxies`.
+func headerSourceGetter(kind SourceKind, getterFunc GetterFunc2, name func(r *http.Request) string) Option {
+	return func(opts *options) {
+		namedSourceGetter(kind, getterFunc, name)(opts)
+		opts.getters[len(opts.getters)-1].readsHeaders = true
+	}
+}
+
+// pathSourceGetter registers a getter which can also report
+// a path to rewrite the request path with, see `sourceFunc`.
+func pathSourceGetter(kind SourceKind, getterFunc sourceFunc) Option {
+	return func(opts *options) {
+		opts.getters = append(opts.getters, source{kind: kind, get: getterFunc})
+	}
+}
+
+// StopAfterSource stops the getters chain after a source of the given "kind"
+// was present, even if it was empty, so the next sources are not consulted.
+// Useful when clients reliably use exactly one mechanism.
+//
+// Example Code:
+//
+//	StopAfterSource(SourceHeader) // if any header was sent, form and query are ignored.
+func StopAfterSource(kind SourceKind) Option {
+	return func(opts *options) {
+		opts.stopAfterSources = append(opts.stopAfterSources, kind)
+	}
+}
+
+// TrustedProxies allows the header-based override, see `Headers`,
+// only for requests coming directly from the given networks,
+// e.g. a gateway which sets the override headers itself,
+// otherwise the getters which read the request headers are skipped:
+// `Headers`, `HeaderDecoded`, `FragmentHeader`, `AcceptParam`, `PreferParam` and `ServerVar`.
+// Other sources, such as form fields and URL queries, are unaffected.
+//
+// The "cidrs" are CIDR notations, e.g. "10.0.0.0/8" or "fd00::/8", or single IP addresses.
+// The request's remote address, not the X-Forwarded-For header which
+// can be spoofed by clients, is checked. Invalid values are reported by `NewStrict`.
+func TrustedProxies(cidrs ...string) Option {
+	return func(opts *options) {
+		for _, cidr := range cidrs {
+			if !strings.Contains(cidr, "/") {
+				if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+					cidr += "/32"
+				} else {
+					cidr += "/128"
+				}
+			}
+
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				opts.errs = append(opts.errs, fmt.Errorf("methodoverride: trusted proxies: %w", err))
+				continue
+			}
+
+			opts.trustedProxies = append(opts.trustedProxies, network)
+		}
+	}
+}
+
+// DiagnosticsHeader sets a response header which traces the sources checked,
+// by order, when a request could be overridden but no source matched,
+// e.g. "X-Method-Override-Trace: header:empty;form:absent;query:absent".
+// Gate it behind a debug flag so production responses are not polluted.
+//
+// Defaults to empty, no header is set.
+func DiagnosticsHeader(name string) Option {
+	return func(opts *options) {
+		opts.diagnosticsHeader = name
+	}
+}
+
+// MaxGetters sets the maximum number of registered getters,
+// a guardrail for generated configurations which may register
+// the same getters again and again by mistake.
+// `NewStrict` reports an error when more than "n" getters are registered,
+// `New` does not check it.
+//
+// Defaults to 0, no limit.
+func MaxGetters(n int) Option {
+	return func(opts *options) {
+		opts.maxGetters = n
+	}
+}
+
+// ConcurrentGetters runs the custom getters, see `Getter`, `Getter2` and `GetterE`,
+// concurrently, for getters doing expensive work, e.g. external calls.
+// The result still respects the registration order:
+// the first non-empty value, by order, is used,
+// no matter which getter finished first.
+// The builtin getters, including the `RichGetter`, run sequentially,
+// as they may read the request body or modify the request.
+//
+// Custom getters should be safe for concurrent use
+// and should not read the request body, the request form values
+// or modify the request and the response headers.
+// Note that they all run, even if a previous getter resolved the method,
+// and the next handler is called once all of them returned.
+//
+// Defaults to false, the getters run sequentially.
+func ConcurrentGetters() Option {
+	return func(opts *options) {
+		opts.concurrentGetters = true
+	}
+}
+
+// StopOnEmpty stops the getters chain when a source
+// is present but empty, e.g. "_method=", so no override occurs.
+// This respects a client's explicit intent to not override.
+//
+// Defaults to false, the next getter is checked.
+func StopOnEmpty() Option {
+	return func(opts *options) {
+		opts.stopOnEmpty = true
+	}
+}
+
+// GetterFuncE is like `GetterFunc` but it can report an error,
+// e.g. on a failed body decode or external lookup.
+type GetterFuncE func(http.ResponseWriter, *http.Request) (string, error)
+
+// GetterE sets a custom logic, which may fail, to use to extract the method name
+// to override the POST method with.
+// On failure the "errorHandler" is called, if not nil,
+// and the next getter is checked.
+func GetterE(customFunc GetterFuncE, errorHandler func(error)) Option {
+	return Getter(func(w http.ResponseWriter, r *http.Request) string {
+		v, err := customFunc(w, r)
+		if err != nil {
+			if errorHandler != nil {
+				errorHandler(err)
+			}
+
+			return ""
+		}
+
+		return v
+	})
+}
+
+// Headers that client can send to specify a method
+// to override the POST method with.
+//
+// Defaults to:
+// X-HTTP-Method
+// X-HTTP-Method-Override
+// X-Method-Override
+//
+// Header names are canonicalized, a name with underscores matches
+// both its own header and its hyphenated one,
+// e.g. "x_http_method" matches the "X_http_method" and "X-Http-Method" headers.
+// Lowercased header keys, e.g. set by HTTP/2 stacks
+// which do not canonicalize them, are matched too.
+func Headers(headers ...string) Option {
+	// the candidate keys of each header, by order:
+	// the canonical key and, if it contains underscores, its hyphenated one.
+	keys := make([][]string, len(headers))
+	lowerKeys := make([][]string, len(headers))
+	for i, s := range headers {
+		keys[i] = []string{textproto.CanonicalMIMEHeaderKey(s)}
+		if strings.Contains(s, "_") {
+			keys[i] = append(keys[i], textproto.CanonicalMIMEHeaderKey(strings.ReplaceAll(s, "_", "-")))
+		}
+
+		for _, key := range keys[i] {
+			lowerKeys[i] = append(lowerKeys[i], strings.ToLower(key))
+		}
+	}
+
+	// lookup returns the index of the first non-empty header, its matched key and its value.
+	lookup := func(r *http.Request) (int, string, string, bool) {
+		present := false
+		for i := range keys {
+			for j, key := range keys[i] {
+				values := r.Header[key]
+				if len(values) == 0 {
+					values = r.Header[lowerKeys[i][j]]
+				}
+
+				if len(values) > 0 {
+					if values[0] != "" {
+						return i, key, values[0], true
+					}
+
+					present = true
+				}
+			}
+		}
+
+		return -1, "", "", present
+	}
+
+	return func(opts *options) {
+		opts.headers = append(opts.headers, headers...)
+		for i := range keys {
+			opts.headerKeys = append(opts.headerKeys, keys[i]...)
+		}
+
+		headerSourceGetter(SourceHeader, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			i, key, v, present := lookup(r)
+			if i >= 0 && !opts.varyAllHeaders {
+				w.Header().Add("Vary", key)
+			}
+
+			return v, present
+		}, func(r *http.Request) string {
+			if i, _, _, _ := lookup(r); i >= 0 {
+				return headers[i]
+			}
+
+			return ""
+		})(opts)
+	}
+}
+
+// HeaderDecoded same as `Headers` but it accepts a single header name
+// and the "decode" function is applied to the header's value
+// before it is used as the method, an empty result means no method,
+// e.g. for gRPC-Web style clients which send base64-encoded or comma-joined values.
+//
+// Example Code:
+//
+//	HeaderDecoded("X-Grpc-Web-Method", func(v string) string {
+//		b, err := base64.StdEncoding.DecodeString(v)
+//		if err != nil {
+//			return ""
+//		}
+//		return string(b)
+//	})
+func HeaderDecoded(name string, decode func(value string) string) Option {
+	key := textproto.CanonicalMIMEHeaderKey(name)
+
+	return func(opts *options) {
+		opts.headers = append(opts.headers, name)
+		opts.headerKeys = append(opts.headerKeys, key)
+		headerSourceGetter(SourceHeader, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			values := r.Header[key]
+			if len(values) == 0 {
+				return "", false
+			}
+
+			v := decode(values[0])
+			if v != "" && !opts.varyAllHeaders {
+				w.Header().Add("Vary", key)
+			}
+
+			return v, true
+		}, func(*http.Request) string { return name })(opts)
+	}
+}
+
+// FragmentHeader specifies a header, set by a proxy, which holds the original request URI
+// along with its fragment, and the fragment parameter name to use
+// to determinate the method to override the POST method with.
+// Like the `Headers`, it respects the `TrustedProxies` and `VaryAllHeaders` options.
+//
+// Example Code:
+//
+//	FragmentHeader("X-Original-URI", "_method")
+//
+// Example Header:
+// X-Original-URI: /path#_method=DELETE
+func FragmentHeader(headerName, paramName string) Option {
+	key := textproto.CanonicalMIMEHeaderKey(headerName)
+
+	return func(opts *options) {
+		opts.headers = append(opts.headers, headerName)
+		opts.headerKeys = append(opts.headerKeys, key)
+		headerSourceGetter(SourceHeader, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			uri := r.Header.Get(key)
+			hash := strings.IndexByte(uri, '#')
+			if hash < 0 {
+				return "", false
+			}
+
+			params, err := url.ParseQuery(uri[hash+1:])
+			if err != nil {
+				return "", false
+			}
+
+			if v, ok := params[paramName]; ok {
+				if v[0] != "" && !opts.varyAllHeaders {
+					w.Header().Add("Vary", key)
+				}
+
+				return v[0], true
+			}
+
+			return "", false
+		}, func(*http.Request) string { return headerName })(opts)
+	}
+}
+
+// ServerVarsFunc is the type signature of the server variables source,
+// it reports the value of the "name" server variable and whether it is present.
+// See `ServerVars` and `ServerVar`.
+type ServerVarsFunc func(r *http.Request, name string) (string, bool)
+
+// ServerVars sets the source function of the server variables, see `ServerVar`.
+// Defaults to reading the request headers, a name with the "HTTP_" prefix,
+// e.g. "HTTP_X_HTTP_METHOD", is read from its header, e.g. "X-Http-Method".
+//
+// Useful for CGI and FastCGI deployments where the standard headers are mangled.
+func ServerVars(fn ServerVarsFunc) Option {
+	return func(opts *options) {
+		opts.serverVars = fn
+	}
+}
+
+// ServerVar specifies a server variable name to determinate
+// the method to override the POST method with,
+// it is read from the source function set by `ServerVars`.
+// Like the `Headers`, it respects the `TrustedProxies` option,
+// the server variables carry the request headers.
+//
+// Example Code:
+//
+//	ServerVar("HTTP_X_HTTP_METHOD")
+func ServerVar(name string) Option {
+	return func(opts *options) {
+		headerSourceGetter(SourceServerVar, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			if opts.serverVars != nil {
+				return opts.serverVars(r, name)
+			}
+
+			return headerServerVar(r, name)
+		}, nil)(opts)
+	}
+}
+
+func headerServerVar(r *http.Request, name string) (string, bool) {
+	if strings.HasPrefix(name, "HTTP_") {
+		name = strings.Replace(name[len("HTTP_"):], "_", "-", -1)
+	}
+
+	values, ok := r.Header[textproto.CanonicalMIMEHeaderKey(name)]
+	if !ok {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// VaryAllHeaders adds every registered header name, see `Headers`,
+// to the Vary response header of a request which could be overridden,
+// no matter which one, if any, matched.
+// Use it for correct cache keys on CDNs.
+//
+// Defaults to false, only the matched header name is added.
+func VaryAllHeaders() Option {
+	return func(opts *options) {
+		opts.varyAllHeaders = true
+	}
+}
+
+const postMaxMemory = 32 << 20
+
+// FormField specifies a form field to use to determinate the method
+// to override the POST method with.
+// The form of a request body includes its url queries, as the `http.Request.ParseForm` does,
+// but a POST, PUT or PATCH request without a body is not parsed at all,
+// unlike the `http.Request.FormValue` its url queries are not checked,
+// register the `Query` getter for them, as the defaults do.
+//
+// Example Field:
+// <input type="hidden" name="_method" value="DELETE">
+//
+// Defaults to: "_method".
+func FormField(fieldName string) Option {
+	return FormFields(fieldName)
+}
+
+// FormFields same as `FormField` but it accepts more than one field names,
+// the first non-empty value, by order, is used.
+// The request form is parsed once.
+//
+// Example Code:
+//
+//	FormFields("_method", "__method")
+func FormFields(fieldNames ...string) Option {
+	return func(opts *options) {
+		// lookup returns the index of the first non-empty field and its value.
+		lookup := func(r *http.Request) (int, string, bool) {
+			present := false
+			if form, has := opts.form(r); has {
+				for i, fieldName := range fieldNames {
+					if v := form[fieldName]; len(v) > 0 {
+						if v[0] != "" {
+							return i, v[0], true
+						}
+
+						present = true
+					}
+				}
+			}
+			return -1, "", present
+		}
+
+		opts.formFields = append(opts.formFields, fieldNames...)
+		namedSourceGetter(SourceForm, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			_, v, present := lookup(r)
+			return v, present
+		}, func(r *http.Request) string {
+			if i, _, _ := lookup(r); i >= 0 {
+				return fieldNames[i]
+			}
+
+			return ""
+		})(opts)
+	}
+}
+
+// MultipartField specifies a multipart form field to use to determinate the method
+// to override the POST method with. Unlike `FormField`,
+// urlencoded bodies and URL queries are ignored,
+// as multipart forms are harder to forge from a plain HTML form.
+//
+// Example Code:
+//
+//	New(Only(MultipartField("_method")))
+func MultipartField(fieldName string) Option {
+	return func(opts *options) {
+		sourceGetter(SourceForm, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			if r.MultipartForm == nil {
+				if !isMultipartForm(r) {
+					return "", false
+				}
+
+				opts.form(r) // parses the multipart form.
+			}
+
+			if m := r.MultipartForm; m != nil {
+				if v := m.Value[fieldName]; len(v) > 0 {
+					return v[0], true
+				}
+			}
+
+			return "", false
+		})(opts)
+	}
+}
+
+// FieldMap specifies a form field or URL query parameter whose value
+// is translated through the "mapping" to the method to override the POST method with.
+// Unmapped values are ignored and the next getter is checked.
+// Useful for constrained clients which send short codes to save bytes.
+// It registers a form getter and a URL query getter, by order.
+//
+// Example Code:
+//
+//	FieldMap("_m", map[string]string{"1": "PUT", "2": "PATCH", "3": "DELETE"})
+func FieldMap(fieldName string, mapping map[string]string) Option {
+	lookup := func(values []string) (string, bool) {
+		if len(values) > 0 {
+			if method, ok := mapping[values[0]]; ok {
+				return method, method != ""
+			}
+		}
+
+		return "", false
+	}
+
+	name := func(*http.Request) string { return fieldName }
+
+	return func(opts *options) {
+		// a form and a query getter, so the source kind reports where the value came from.
+		namedSourceGetter(SourceForm, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			form, has := opts.form(r)
+			if !has {
+				return "", false
+			}
+
+			if r.PostForm != nil {
+				// the body values only, the url query is checked by the next getter.
+				return lookup(r.PostForm[fieldName])
+			}
+
+			return lookup(form[fieldName])
+		}, name)(opts)
+
+		namedSourceGetter(SourceQuery, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			if r.URL.RawQuery == "" {
+				return "", false
+			}
+
+			return lookup(r.URL.Query()[fieldName])
+		}, name)(opts)
+	}
+}
+
+// JSONField specifies a field of a JSON object request body
+// to use to determinate the method to override the POST method with.
+// See `JSONPath` for nested fields.
+//
+// Example Body:
+// {"_method": "DELETE"}
+func JSONField(fieldName string) Option {
+	return jsonGetter([]string{fieldName})
+}
+
+// JSONPath same as `JSONField` but it accepts a dotted path of a nested field.
+// If any segment of the path is missing or the field is not a string
+// then the next getter is checked.
+//
+// Example Code:
+//
+//	JSONPath("meta.method")
+//
+// Example Body:
+// {"meta": {"method": "DELETE"}}
+func JSONPath(path string) Option {
+	return jsonGetter(strings.Split(path, "."))
+}
+
+// jsonGetter reads and resets the request body, if its Content-Type is a JSON one,
+// respecting the `MaxBodyScan`, `NoBodyRead` and `FormBodyMethods` options.
+func jsonGetter(path []string) Option {
+	return func(opts *options) {
+		sourceGetter(SourceJSON, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			if !isJSON(r) {
+				return "", false
+			}
+
+			body := opts.bodyCopy(r)
+			if body == nil {
+				return "", false
+			}
+
+			var v interface{}
+			err := json.NewDecoder(body).Decode(&v)
+			body.Seek(0, io.SeekStart)
+			if err != nil {
+				return "", false
+			}
+
+			for _, segment := range path {
+				object, ok := v.(map[string]interface{})
+				if !ok {
+					return "", false
+				}
+
+				if v, ok = object[segment]; !ok {
+					return "", false
+				}
+			}
+
+			method, ok := v.(string)
+			return method, ok
+		})(opts)
+	}
+}
+
+// isJSON reports whether the request body is a JSON one, e.g. "application/json"
+// or "application/vnd.api+json".
+func isJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// bodyCopy returns the rewound request body copy, reading the body if not already read,
+// or nil if the body cannot be read, e.g. it is too large.
+func (o *options) bodyCopy(r *http.Request) io.ReadSeeker {
+	if body, ok := r.Body.(scannedBody); ok {
+		return body.copy
+	}
+
+	if o.noBodyRead || !hasBody(r) {
+		return nil
+	}
+
+	if o.formBodyMethods == nil {
+		if m := r.Method; m != http.MethodPost && m != http.MethodPut && m != http.MethodPatch {
+			return nil
+		}
+	} else if !o.isFormBodyMethod(r.Method) {
+		return nil
+	}
+
+	body, err := getBody(r, o.maxBodyScan, o.spillThreshold, true)
+	if err != nil {
+		o.bodyError(r, err)
+	}
+
+	return body
+}
+
+// MaxBodyScan sets the maximum number of request body bytes
+// that can be read in order to detect a form field.
+// If the body is larger than "n" bytes the form detection is skipped
+// and the request body is left untouched for the next handler.
+// This protects the server from buffering large bodies on every POST request.
+//
+// Note that this is not the same as the multipart form's maximum memory.
+//
+// Defaults to 0, no limit.
+func MaxBodyScan(n int64) Option {
+	return func(opts *options) {
+		opts.maxBodyScan = n
+	}
+}
+
+// FormFieldMaxLen skips the request body reading on form detection
+// when the request's declared Content-Length is unknown or larger than "n" bytes,
+// so the method is checked on the next getters, e.g. the headers or the url query.
+// Unlike the `MaxBodyScan`, the body is never read to find out its length,
+// it bounds the worst-case work to the common small form case.
+//
+// Defaults to 0, no limit.
+func FormFieldMaxLen(n int64) Option {
+	return func(opts *options) {
+		opts.formFieldMaxLen = n
+	}
+}
+
+// FormParserFunc is the type signature for declaring custom logic
+// to parse the request form values, see `FormParser`.
+type FormParserFunc func(r *http.Request) (form map[string][]string, found bool)
+
+// FormParser sets a custom logic to parse the request form values
+// which the form getters, e.g. `FormField`, use instead of the builtin one,
+// e.g. to support form data sent with a non-standard encoding.
+// The "parser" is responsible for resetting the request body, if it reads it,
+// the `MaxBodyScan` and `NoBodyRead` options do not apply.
+//
+// Defaults to nil, the net/http form parsing is used.
+func FormParser(parser FormParserFunc) Option {
+	return func(opts *options) {
+		opts.formParser = parser
+	}
+}
+
+// OnBodyError registers a handler which is notified when the request body,
+// read to detect a form field or a trailer, fails to be read,
+// e.g. when a client sends a truncated body.
+// The request is still served, without an override from the body.
+// A body larger than `MaxBodyScan` is not reported.
+//
+// Example Code:
+//
+//	OnBodyError(func(r *http.Request, err error) {
+//		log.Printf("method override: %s: %v", r.RemoteAddr, err)
+//	})
+//
+// Defaults to nil, errors are silently ignored.
+func OnBodyError(handler func(r *http.Request, err error)) Option {
+	return func(opts *options) {
+		opts.bodyErrorHandler = handler
+	}
+}
+
+// SpillToDisk buffers the request body, read to detect a form field,
+// to a temporary file instead of memory when it is larger than "threshold" bytes,
+// like the multipart form's files do.
+// The reset request body reads from that file
+// and the file is removed once the request is served.
+//
+// Defaults to 0, the body is buffered in memory.
+func SpillToDisk(threshold int64) Option {
+	return func(opts *options) {
+		opts.spillThreshold = threshold
+	}
+}
+
+// NoBodyRead disables the request body reading on form detection,
+// only the already parsed request form values are checked.
+// Use it on endpoints receiving large or streaming bodies:
+// the body arrives untouched to the next handler,
+// at the cost of not detecting a form field sent through the body.
+func NoBodyRead() Option {
+	return func(opts *options) {
+		opts.noBodyRead = true
+	}
+}
+
+// FormBodyMethods sets the request methods which their body
+// can be read on form detection, on any other method
+// only the URL query and the already parsed form values are checked.
+//
+// Note that net/http parses form bodies only for the "POST", "PUT" and "PATCH" methods,
+// so a form field sent through the body of a GET request is never detected,
+// use the URL query to override a GET request instead, e.g. "/search?_method=PROPFIND".
+//
+// Defaults to "POST", "PUT" and "PATCH".
+func FormBodyMethods(methods ...string) Option {
+	for i, s := range methods {
+		methods[i] = strings.ToUpper(s)
+	}
+
+	return func(opts *options) {
+		opts.formBodyMethods = methods
+	}
+}
+
+func (o *options) isFormBodyMethod(method string) bool {
+	if o.formBodyMethods == nil {
+		return true // let getForm decide.
+	}
+
+	for _, s := range o.formBodyMethods {
+		if s == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// form returns the request form values based on the body reading options.
+func (o *options) form(r *http.Request) (map[string][]string, bool) {
+	if o.cacheParsedForm {
+		if form, cached := FormFromContext(r); cached {
+			return form, true
+		}
+	}
+
+	if o.formParser != nil {
+		return o.formParser(r)
+	}
+
+	if o.noBodyRead || (o.formFieldMaxLen > 0 && (r.ContentLength < 0 || r.ContentLength > o.formFieldMaxLen)) {
+		return cachedForm(r)
+	}
+
+	if !o.isFormBodyMethod(r.Method) {
+		if form, found := cachedForm(r); found {
+			return form, true
+		}
+
+		if r.URL.RawQuery != "" {
+			if query := r.URL.Query(); len(query) > 0 {
+				return query, true
+			}
+		}
+
+		return nil, false
+	}
+
+	form, found, err := getForm(r, postMaxMemory, o.maxBodyScan, o.spillThreshold, true)
+	if err != nil {
+		o.bodyError(r, err)
+	}
+
+	return form, found
+}
+
+func (o *options) bodyError(r *http.Request, err error) {
+	if o.bodyErrorHandler != nil && err != errBodyTooLarge {
+		o.bodyErrorHandler(r, err)
+	}
+}
+
+type parsedFormContextKey struct{}
+
+// CacheParsedForm saves the request form values parsed by the form getters
+// on the request context, see `FormFromContext`,
+// so the next middlewares, or another method override wrapper with this option,
+// reuse them instead of parsing the request form again,
+// even if they receive a copy of the request.
+//
+// Defaults to false.
+func CacheParsedForm() Option {
+	return func(opts *options) {
+		opts.cacheParsedForm = true
+	}
+}
+
+// FormFromContext returns the request form values saved on the request context,
+// see `CacheParsedForm`.
+func FormFromContext(r *http.Request) (map[string][]string, bool) {
+	form, ok := r.Context().Value(parsedFormContextKey{}).(map[string][]string)
+	return form, ok
+}
+
+// ParsedForm returns the request form (url queries, post or multipart) values,
+// for custom getters which need them, see `Getter`.
+// The form values already parsed by a previous getter, e.g. `FormField`,
+// are reused, the request body is read at most once and it is reset,
+// so it is still available to the next handlers.
+//
+// Note that the `MaxBodyScan` and `NoBodyRead` options do not apply here.
+func ParsedForm(r *http.Request) (map[string][]string, bool) {
+	form, found, _ := getForm(r, postMaxMemory, 0, 0, true)
+	return form, found
+}
+
+// getForm returns the request form (url queries, post or multipart) values,
+// a POST, PUT or PATCH request without a body is not parsed, see `FormField`.
+// The returned error is the request body read error, if any.
+func getForm(r *http.Request, postMaxMemory, maxBodyScan, spillThreshold int64, resetBody bool) (form map[string][]string, found bool, bodyErr error) {
+	/*
+		net/http/request.go#1219
+		for k, v := range f.Value {
+			r.Form[k] = append(r.Form[k], v...)
+			// r.PostForm should also be populated. See Issue 9305.
+			r.PostForm[k] = append(r.PostForm[k], v...)
+		}
+	*/
+
+	if form, found := cachedForm(r); found {
+		return form, true, nil
+	}
+
+	var bodyCopy io.ReadSeeker
+
+	if body, ok := r.Body.(scannedBody); ok {
+		// already read by a previous getter, e.g. the `Trailer`,
+		// the form values are parsed from its copy.
+		if body.copy == nil {
+			// too large or errored, nothing to parse.
+			return nil, false, nil
+		}
+
+		bodyCopy, resetBody = body.copy, true
+		bodyCopy.Seek(0, io.SeekStart)
+	} else if resetBody {
+		// on POST, PUT and PATCH it will read the form values from request body otherwise from URL queries.
+		if m := r.Method; m == "POST" || m == "PUT" || m == "PATCH" {
+			if !hasBody(r) {
+				// fast path, nothing to parse.
+				return nil, false, nil
+			}
+
+			bodyCopy, bodyErr = getBody(r, maxBodyScan, spillThreshold, resetBody)
+			if bodyCopy == nil {
+				// too large, errored or already consumed by a previous handler
+				// which did not fill the request form values, nothing to parse.
+				return nil, false, bodyErr
+			}
+			// r.Body = ioutil.NopCloser(io.TeeReader(r.Body, buf))
+		} else {
+			resetBody = false
+		}
+	}
+
+	var err error
+	if isMultipartForm(r) {
+		// ParseMultipartForm calls `request.ParseForm` automatically
+		// therefore we don't need to call it here, although it doesn't hurt.
+		// After one call to ParseMultipartForm or ParseForm,
+		// subsequent calls have no effect, are idempotent.
+		err = r.ParseMultipartForm(postMaxMemory)
+	} else {
+		// lighter than ParseMultipartForm for urlencoded bodies and URL queries.
+		err = r.ParseForm()
+	}
+	if resetBody {
+		// the request body reads from the body copy, rewind it.
+		bodyCopy.Seek(0, io.SeekStart)
+	}
+	if err != nil && err != http.ErrNotMultipart {
+		return nil, false, nil
+	}
+
+	form, found = cachedForm(r)
+	return form, found, nil
+}
+
+// isMultipartForm reports whether the request body is a multipart form.
+func isMultipartForm(r *http.Request) bool {
+	const mediaType = "multipart/form-data"
+	contentType := r.Header.Get("Content-Type")
+	return len(contentType) >= len(mediaType) && strings.EqualFold(contentType[:len(mediaType)], mediaType)
+}
+
+// cachedForm returns the already parsed request form values, if any.
+func cachedForm(r *http.Request) (form map[string][]string, found bool) {
+	if form := r.Form; len(form) > 0 {
+		return form, true
+	}
+
+	if form := r.PostForm; len(form) > 0 {
+		return form, true
+	}
+
+	if m := r.MultipartForm; m != nil {
+		if len(m.Value) > 0 {
+			return m.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// hasBody reports whether the request may have a body to read.
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+var errBodyTooLarge = errors.New("methodoverride: request body too large")
+
+// getBody reads and returns a copy of the request body, nil if empty.
+// If "limit" is positive and the body is larger than "limit" bytes
+// it stops reading and returns an errBodyTooLarge error.
+// If "spillThreshold" is positive and the body is larger than "spillThreshold" bytes
+// the copy is a temporary file instead of memory, see `removeSpill`.
+//
+// If "resetBody" is true the request body reads from the copy and it is marked as scanned,
+// so the next getters do not read it again, see `isScanned`.
+func getBody(r *http.Request, limit, spillThreshold int64, resetBody bool) (io.ReadSeeker, error) {
+	memLimit := limit
+	if spillThreshold > 0 && (limit <= 0 || spillThreshold < limit) {
+		memLimit = spillThreshold
+	}
+
+	var body io.Reader = r.Body
+	if memLimit > 0 {
+		body = io.LimitReader(r.Body, memLimit+1)
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		if resetBody {
+			r.Body = scannedBody{ReadCloser: r.Body}
+		}
+
+		return nil, err
+	}
+
+	if memLimit > 0 && int64(len(data)) > memLimit {
+		if memLimit != limit {
+			return spillBody(r, data, limit, resetBody)
+		}
+
+		if resetBody {
+			// put back the consumed bytes in front of the unread ones.
+			r.Body = scannedBody{ReadCloser: readCloser{
+				Reader: io.MultiReader(bytes.NewReader(data), r.Body),
+				Closer: r.Body,
+			}}
+		}
+
+		return nil, errBodyTooLarge
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	// * remember, Request.Body has no Bytes(), we have to consume them first
+	// and after re-set them to the body, this is the only solution.
+	bodyCopy := bytes.NewReader(data)
+	if resetBody {
+		r.Body = scannedBody{ReadCloser: ioutil.NopCloser(bodyCopy), copy: bodyCopy}
+	}
+
+	return bodyCopy, nil
+}
+
+// spillBody same as `getBody` but it writes the already read "data"
+// and the rest of the request body to a temporary file.
+func spillBody(r *http.Request, data []byte, limit int64, resetBody bool) (io.ReadSeeker, error) {
+	f, err := ioutil.TempFile("", "methodoverride-")
+	if err != nil {
+		return nil, err
+	}
+
+	var rest io.Reader = r.Body
+	if limit > 0 {
+		rest = io.LimitReader(r.Body, limit+1-int64(len(data)))
+	}
+
+	n, err := io.Copy(f, io.MultiReader(bytes.NewReader(data), rest))
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		if resetBody {
+			r.Body = scannedBody{ReadCloser: r.Body}
+		}
+
+		return nil, err
+	}
+
+	if limit > 0 && n > limit {
+		if !resetBody {
+			f.Close()
+			os.Remove(f.Name())
+			return nil, errBodyTooLarge
+		}
+
+		// put back the consumed bytes in front of the unread ones.
+		r.Body = scannedBody{ReadCloser: readCloser{
+			Reader: io.MultiReader(f, r.Body),
+			Closer: r.Body,
+		}, file: f}
+
+		return nil, errBodyTooLarge
+	}
+
+	if resetBody {
+		r.Body = scannedBody{ReadCloser: ioutil.NopCloser(f), copy: f, file: f}
+	}
+
+	return f, nil
+}
+
+// spilledFile returns the temporary file of the request body, if any, see `SpillToDisk`.
+func spilledFile(r *http.Request) *os.File {
+	if body, ok := r.Body.(scannedBody); ok {
+		return body.file
+	}
+
+	return nil
+}
+
+// removeSpill removes the temporary file of the request body, if any.
+func (o *options) removeSpill(r *http.Request) {
+	if f := spilledFile(r); f != nil {
+		if o.spills != nil {
+			o.spills.remove(f)
+		}
+
+		removeFile(f)
+	}
+}
+
+func removeFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// spillFiles keeps track of the temporary files of the requests being served,
+// see `NewWithCleanup`.
+type spillFiles struct {
+	mu    sync.Mutex
+	files map[*os.File]struct{}
+}
+
+func (s *spillFiles) add(f *os.File) {
+	s.mu.Lock()
+	if s.files == nil {
+		s.files = make(map[*os.File]struct{})
+	}
+	s.files[f] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *spillFiles) remove(f *os.File) {
+	s.mu.Lock()
+	delete(s.files, f)
+	s.mu.Unlock()
+}
+
+func (s *spillFiles) removeAll() {
+	s.mu.Lock()
+	files := s.files
+	s.files = nil
+	s.mu.Unlock()
+
+	for f := range files {
+		removeFile(f)
+	}
+}
+
+// scannedBody is a request body already read by a getter,
+// it is shared by all getters of the request so the body is read at most once.
+type scannedBody struct {
+	io.ReadCloser
+	copy io.ReadSeeker // the body copy, nil if the body was too large or errored.
+	file *os.File      // the temporary file of a spilled body, if any.
+}
+
+// isScanned reports whether the request body was already read by a getter.
+func isScanned(r *http.Request) bool {
+	_, scanned := r.Body.(scannedBody)
+	return scanned
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Query specifies a url parameter name to use to determinate the method
+// to override the POST methos with.
+//
+// Example URL Query string:
+// http://localhost:8080/path?_method=DELETE
+//
+// Defaults to: "_method".
+func Query(paramName string) Option {
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		if r.URL.RawQuery == "" {
+			return "", false
+		}
+
+		if v := r.URL.Query()[paramName]; len(v) > 0 {
+			return v[0], true
+		}
+
+		return "", false
+	}
+
+	return func(opts *options) {
+		opts.queryParams = append(opts.queryParams, paramName)
+		namedSourceGetter(SourceQuery, getterFunc, func(*http.Request) string { return paramName })(opts)
+	}
+}
+
+// QueryFlag specifies the methods which can be sent
+// as a valueless url parameter to override the POST method with,
+// the first matching parameter, by order, is used. Case-insensitive.
+//
+// Example Code:
+//
+//	QueryFlag(http.MethodPut, http.MethodDelete)
+//
+// Example URL:
+// http://localhost:8080/users/42?DELETE
+func QueryFlag(methods ...string) Option {
+	lookup := func(r *http.Request) string {
+		query := r.URL.RawQuery
+		for query != "" {
+			var key string
+			key, query = query, ""
+			if i := strings.IndexByte(key, '&'); i >= 0 {
+				key, query = key[:i], key[i+1:]
+			}
+
+			if key == "" || strings.IndexByte(key, '=') >= 0 {
+				continue
+			}
+
+			if k, err := url.QueryUnescape(key); err == nil {
+				key = k
+			}
+
+			for _, method := range methods {
+				if strings.EqualFold(key, method) {
+					return method
+				}
+			}
+		}
+
+		return ""
+	}
+
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		method := lookup(r)
+		return method, method != ""
+	}
+
+	return namedSourceGetter(SourceQuery, getterFunc, lookup)
+}
+
+// QueryTransform same as `Query` but the "transform" function
+// is applied to the url parameter's value before it is used as the method,
+// an empty result means no method.
+//
+// Example Code:
+//
+//	// ?action=user.delete
+//	QueryTransform("action", func(v string) string {
+//		return v[strings.LastIndexByte(v, '.')+1:]
+//	})
+func QueryTransform(paramName string, transform func(value string) string) Option {
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		if r.URL.RawQuery == "" {
+			return "", false
+		}
+
+		if v := r.URL.Query()[paramName]; len(v) > 0 {
+			return transform(v[0]), true
+		}
+
+		return "", false
+	}
+
+	return sourceGetter(SourceQuery, getterFunc)
+}
+
+// MatrixParam specifies a matrix URI parameter name to use to determinate the method
+// to override the POST method with. All path segments are checked by order.
+// If "strip" is true and the parameter was found
+// then all matrix parameters are removed from the request path,
+// once the override is applied.
+//
+// Example URL:
+// http://localhost:8080/path;_method=DELETE
+func MatrixParam(paramName string, strip bool) Option {
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+		if !strings.Contains(r.URL.Path, ";") {
+			return "", "", false
+		}
+
+		var (
+			method  string
+			present bool
+		)
+
+		segments := strings.Split(r.URL.Path, "/")
+		for i, segment := range segments {
+			params := strings.Split(segment, ";")
+			for _, param := range params[1:] {
+				if eq := strings.IndexByte(param, '='); !present && eq > 0 && param[:eq] == paramName {
+					method, present = param[eq+1:], true
+				}
+			}
+
+			segments[i] = params[0]
+		}
+
+		path := ""
+		if method != "" && strip {
+			path = strings.Join(segments, "/")
+		}
+
+		return method, path, present
+	}
+
+	return pathSourceGetter(SourceMatrix, getterFunc)
+}
+
+// PreferParam specifies a preference name of the Prefer header, see RFC 7240,
+// to use to determinate the method to override the POST method with.
+//
+// Example Header:
+// Prefer: method=delete, respond-async
+//
+// Multiple preferences and Prefer values are checked by order,
+// quoted values are supported.
+// Like the `Headers`, it respects the `TrustedProxies` option.
+func PreferParam(name string) Option {
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		present := false
+		for _, prefer := range r.Header["Prefer"] {
+			for _, preference := range splitQuoted(prefer, ',') {
+				// the preference's parameters, if any, are ignored.
+				preference = splitQuoted(preference, ';')[0]
+
+				token, value := preference, ""
+				if eq := strings.IndexByte(preference, '='); eq >= 0 {
+					token, value = preference[:eq], unquote(strings.TrimSpace(preference[eq+1:]))
+				}
+
+				if strings.EqualFold(strings.TrimSpace(token), name) {
+					if value != "" {
+						w.Header().Add("Vary", "Prefer")
+						return value, true
+					}
+
+					present = true
+				}
+			}
+		}
+
+		return "", present
+	}
+
+	return headerSourceGetter(SourcePrefer, getterFunc, nil)
+}
+
+// splitQuoted splits "s" by the "sep" byte, separators inside quoted strings are ignored.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++ // skip the escaped byte.
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// unquote returns the value of the quoted string "s" or "s" as it is if not quoted.
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b = append(b, s[i])
+	}
+
+	return string(b)
+}
+
+// PathPrefixVerb specifies a mapping of the first path segment
+// to the method to override the POST method with.
+// If "rewrite" is true and the segment was mapped
+// then it is removed from the request path, once the override is applied.
+//
+// Example Code:
+//
+//	PathPrefixVerb(map[string]string{"delete": "DELETE", "update": "PUT"}, true)
+//
+// Example URL:
+// http://localhost:8080/delete/users/42 (becomes DELETE /users/42)
+func PathPrefixVerb(mapping map[string]string, rewrite bool) Option {
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+		path := strings.TrimLeft(r.URL.Path, "/")
+		if path == "" {
+			return "", "", false
+		}
+
+		segment, rest := path, ""
+		if slash := strings.IndexByte(path, '/'); slash >= 0 {
+			segment, rest = path[:slash], path[slash:]
+		}
+
+		method, ok := mapping[segment]
+		if !ok || method == "" {
+			return "", "", false
+		}
+
+		if !rewrite {
+			return method, "", true
+		}
+
+		if rest == "" {
+			rest = "/"
+		}
+
+		return method, rest, true
+	}
+
+	return pathSourceGetter(SourcePath, getterFunc)
+}
+
+// PathParam specifies the index of the path segment
+// to use as the method to override the POST method with.
+// A negative "segmentIndex" counts from the end, e.g. -1 is the last segment.
+// The segment is used only if it is a known method, case-insensitive,
+// see `StrictMethods` and `AllowedTargetMethods`, so "/users/42" is left as it is.
+// If "rewrite" is true and the segment was used
+// then it is removed from the request path, once the override is applied.
+//
+// Example Code:
+//
+//	PathParam(-1, true)
+//
+// Example URL:
+// http://localhost:8080/users/42/DELETE (becomes DELETE /users/42)
+func PathParam(segmentIndex int, rewrite bool) Option {
+	return func(opts *options) {
+		pathSourceGetter(SourcePath, func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+			path := strings.Trim(r.URL.Path, "/")
+			if path == "" {
+				return "", "", false
+			}
+
+			segments := strings.Split(path, "/")
+			i := segmentIndex
+			if i < 0 {
+				i += len(segments)
+			}
+
+			if i < 0 || i >= len(segments) {
+				return "", "", false
+			}
+
+			method := strings.ToUpper(segments[i])
+			if !opts.isKnownMethod(method) {
+				return "", "", false
+			}
+
+			if !rewrite {
+				return method, "", true
+			}
+
+			rest := "/" + strings.Join(append(segments[:i:i], segments[i+1:]...), "/")
+			if len(rest) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+				rest += "/"
+			}
+
+			return method, rest, true
+		})(opts)
+	}
+}
+
+// Trailer specifies trailer header names that client can send to specify a method
+// to override the POST method with.
+// Trailers are available only after the request body was read,
+// so this getter reads the body (respecting the `MaxBodyScan` and `NoBodyRead` options)
+// and resets it for the next handler. The body is read at most once,
+// the form getters, e.g. `FormField`, parse the same copy in any registration order.
+func Trailer(names ...string) Option {
+	keys := make([]string, len(names))
+	for i, s := range names {
+		keys[i] = textproto.CanonicalMIMEHeaderKey(s)
+	}
+
+	lookup := func(r *http.Request) (string, bool) {
+		present := false
+		for _, key := range keys {
+			if values := r.Trailer[key]; len(values) > 0 {
+				if values[0] != "" {
+					return values[0], true
+				}
+
+				present = true
+			}
+		}
+
+		return "", present
+	}
+
+	return func(opts *options) {
+		sourceGetter(SourceTrailer, func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			if len(r.Trailer) == 0 { // no trailers declared.
+				return "", false
+			}
+
+			if v, present := lookup(r); v != "" || opts.noBodyRead || !hasBody(r) || isScanned(r) {
+				return v, present
+			}
+
+			if _, err := getBody(r, opts.maxBodyScan, opts.spillThreshold, true); err != nil {
+				opts.bodyError(r, err)
+				return "", false
+			}
+
+			return lookup(r)
+		})(opts)
+	}
+}
+
+// ContextGetter specifies a request context key to use to determinate the method
+// to override the POST method with. The value should be a string,
+// e.g. placed by a previous authentication middleware.
+//
+// Example Code:
+//
+//	r = r.WithContext(context.WithValue(r.Context(), key, http.MethodDelete))
+//
+// An authentication middleware which runs before the method override wrapper
+// can stash a custom token claim this way, i.e. for batch clients:
+//
+//	auth := func(next http.Handler) http.Handler {
+//	    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	        claims := verifyToken(r) // parse and verify the bearer token.
+//	        if method, ok := claims["method"].(string); ok {
+//	            r = r.WithContext(context.WithValue(r.Context(), methodClaimKey{}, method))
+//	        }
+//	        next.ServeHTTP(w, r)
+//	    })
+//	}
+//
+//	http.ListenAndServe(":8080", auth(New(ContextGetter(methodClaimKey{}))(router)))
+func ContextGetter(key interface{}) Option {
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		v, ok := r.Context().Value(key).(string)
+		return v, ok
+	}
+
+	return sourceGetter(SourceContext, getterFunc)
+}
+
+// AcceptParam specifies a media type parameter name of the Accept header
+// to use to determinate the method to override the POST method with.
+//
+// Example Header:
+// Accept: application/vnd.api+json; method=delete
+//
+// Multiple Accept values are checked by order,
+// malformed media types are ignored.
+// Like the `Headers`, it respects the `TrustedProxies` option.
+func AcceptParam(paramName string) Option {
+	getterFunc := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		present := false
+		for _, accept := range r.Header["Accept"] {
+			for _, mediaType := range strings.Split(accept, ",") {
+				_, params, err := mime.ParseMediaType(mediaType)
+				if err != nil {
+					continue
+				}
+
+				if v, ok := params[strings.ToLower(paramName)]; ok {
+					if v != "" {
+						w.Header().Add("Vary", "Accept")
+						return v, true
+					}
+
+					present = true
+				}
+			}
+		}
+
+		return "", present
+	}
+
+	return headerSourceGetter(SourceAccept, getterFunc, nil)
+}
+
+// RequireAgreement registers a getter which yields a method only when
+// the getters of the "a" and "b" options both yield the same method,
+// e.g. a header and a form field, to prevent single-vector forgery.
+// On a mismatch the next getter is checked, use it along with `Only`.
+//
+// Example Code:
+//
+//	New(Only(RequireAgreement(Headers("X-HTTP-Method"), FormField("_method"))))
+func RequireAgreement(a, b Option) Option {
+	return AllOf(a, b)
+}
+
+// AllOf registers a getter which yields a method only when
+// the getters of all the "o" options yield the same method,
+// it is the n-ary form of the `RequireAgreement`.
+// On a disagreement the next getter is checked, use it along with `Only`.
+//
+// Example Code:
+//
+//	New(Only(AllOf(Headers("X-HTTP-Method"), FormField("_method"), Query("_method"))))
+func AllOf(o ...Option) Option {
+	return func(opts *options) {
+		getters := make([]sourceFunc, len(o))
+		for i, opt := range o {
+			getters[i] = opts.capture(opt)
+		}
+
+		pathSourceGetter(SourceAgreement, func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+			if len(getters) == 0 {
+				return "", "", false
+			}
+
+			v, path, present := getters[0](w, r)
+			if v == "" {
+				return "", "", present
+			}
+
+			for _, getter := range getters[1:] {
+				if other, _, _ := getter(w, r); !strings.EqualFold(v, other) {
+					return "", "", false
+				}
+			}
+
+			return v, path, true
+		})(opts)
+	}
+}
+
+// AnyOf registers a getter which yields the first method
+// of the getters of the "o" options, by order,
+// the same way the getters chain does, so a group of getters
+// can be composed as one, e.g. as a member of `AllOf`.
+//
+// Example Code:
+//
+//	New(Only(AllOf(AnyOf(Headers("X-HTTP-Method"), Query("_method")), FormField("_method"))))
+func AnyOf(o ...Option) Option {
+	return func(opts *options) {
+		pathSourceGetter(SourceGroup, opts.capture(o...))(opts)
+	}
+}
+
+// capture applies the "o" options and returns a getter of their getters,
+// which are removed from the getters chain.
+func (o *options) capture(opts ...Option) sourceFunc {
+	n := len(o.getters)
+	o.configure(opts...)
+	if n > len(o.getters) { // getters were reset.
+		n = 0
+	}
+
+	get := o.chainOf(o.getters[n:])
+	o.getters = o.getters[:n]
+	return get
+}
+
+// Rule combines a path matcher with the getters
+// which determine the method of the matching requests, see `AddRule`.
+type Rule struct {
+	// Match reports whether the rule applies to the request path.
+	Match func(path string) bool
+	// Getters are the getter options of the rule, e.g. `FormField("_method")`.
+	// A rule without getters never overrides the matching requests.
+	Getters []Option
+}
+
+type pathRule struct {
+	match func(path string) bool
+	get   sourceFunc
+}
+
+// AddRule registers a "rule". Rules are evaluated by registration order and
+// only the getters of the first rule which matches the request path are consulted,
+// e.g. to never override the requests of a path, even if they send the form field.
+// All rules take the place of the first registered rule in the getters chain,
+// use it along with `Only` to consult the rules only.
+//
+// Example Code:
+//
+//	New(Only(),
+//		AddRule(Rule{Match: func(path string) bool { return strings.HasPrefix(path, "/reports/") }}),
+//		AddRule(Rule{Match: func(string) bool { return true }, Getters: []Option{FormField("_method")}}),
+//	)
+func AddRule(rule Rule) Option {
+	return func(opts *options) {
+		get := opts.capture(rule.Getters...)
+
+		registered := false
+		for _, getter := range opts.getters {
+			if getter.kind == SourceRule {
+				registered = true
+				break
+			}
+		}
+
+		if !registered {
+			opts.rules = nil
+			pathSourceGetter(SourceRule, opts.matchRule)(opts)
+		}
+
+		opts.rules = append(opts.rules, pathRule{match: rule.Match, get: get})
+	}
+}
+
+// matchRule is the getter of the rules registered by `AddRule`.
+func (o *options) matchRule(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	for _, rule := range o.rules {
+		if rule.match(r.URL.Path) {
+			return rule.get(w, r)
+		}
+	}
+
+	return "", "", false
+}
+
+// chainOf returns a getter of the first non-empty value of the "getters", by order,
+// the getters which read the request headers respect the `TrustedProxies` option.
+func (o *options) chainOf(getters []source) sourceFunc {
+	getters = append([]source(nil), getters...)
+	return func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+		present := false
+		untrusted := o.untrusted(r)
+		for _, getter := range getters {
+			if untrusted && getter.readsHeaders {
+				continue
+			}
+
+			v, path, ok := getter.get(w, r)
+			if v != "" {
+				return v, path, true
+			}
+
+			present = present || ok
+		}
+
+		return "", "", present
+	}
+}
+
+// WhenPathPrefix scopes the getters of the "o" options
+// to the requests which their path starts with the given "prefix",
+// on any other request these getters are not consulted.
+// Note that only getters are scoped,
+// any other option is applied to all requests.
+//
+// Example Code:
+//
+//	New(Only(Headers("X-HTTP-Method")), WhenPathPrefix("/api/", Query("_method")))
+func WhenPathPrefix(prefix string, o ...Option) Option {
+	return WhenPath(func(path string) bool {
+		return strings.HasPrefix(path, prefix)
+	}, o...)
+}
+
+// WhenPath same as `WhenPathPrefix` but the getters of the "o" options
+// are scoped to the requests which their path is accepted by the "match" function,
+// e.g. a regular expression's MatchString method.
+//
+// Example Code:
+//
+//	New(Only(),
+//		WhenPath(regexp.MustCompile(`^/v1/`).MatchString, FormField("_method")),
+//		WhenPath(regexp.MustCompile(`^/v2/`).MatchString, FormField("_verb")),
+//	)
+func WhenPath(match func(path string) bool, o ...Option) Option {
+	return when(func(r *http.Request) bool {
+		return match(r.URL.Path)
+	}, o...)
+}
+
+// WhenHost same as `WhenPathPrefix` but the getters of the "o" options
+// are scoped to the requests which their host, the port is ignored, matches the "host".
+// The "host" is an exact, case-insensitive, host name or a wildcard
+// of any subdomain, e.g. "*.api.example.com" matches "tenant.api.example.com".
+//
+// Example Code:
+//
+//	New(Only(), WhenHost("*.api.example.com", Headers("X-HTTP-Method")))
+func WhenHost(host string, o ...Option) Option {
+	host = strings.ToLower(host)
+	suffix := ""
+	if strings.HasPrefix(host, "*.") {
+		suffix = host[1:] // ".api.example.com"
+	}
+
+	return when(func(r *http.Request) bool {
+		h := r.Host
+		if hostname, _, err := net.SplitHostPort(h); err == nil {
+			h = hostname
+		}
+		h = strings.ToLower(h)
+
+		if suffix != "" {
+			return len(h) > len(suffix) && strings.HasSuffix(h, suffix)
+		}
+
+		return h == host
+	}, o...)
+}
+
+// when scopes the getters registered by the "o" options
+// to the requests that the "match" function returns true.
+func when(match func(*http.Request) bool, o ...Option) Option {
+	return func(opts *options) {
+		n := len(opts.getters)
+		opts.configure(o...)
+		if n > len(opts.getters) { // getters were reset.
+			n = 0
+		}
+
+		for i, getter := range opts.getters[n:] {
+			get := getter.get
+			opts.getters[n+i].get = func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+				if !match(r) {
+					return "", "", false
+				}
+
+				return get(w, r)
+			}
+		}
+	}
+}
+
+// Only clears all default or previously registered values
+// and uses only the "o" option(s).
+//
+// The default behavior is to check for all the following by order:
+// headers, form field, query string
+// and any custom getter (if set).
+// Use this method to override that
+// behavior and use only the passed option(s)
+// to determinate the method to override with.
+//
+// Use cases:
+//
+//  1. When need to check only for headers and ignore other fields:
+//     New(Only(Headers("X-Custom-Header")))
+//
+//  2. When need to check only for (first) form field and (second) custom getter:
+//     New(Only(FormField("fieldName"), Getter(...)))
+func Only(o ...Option) Option {
+	return func(opts *options) {
+		opts.resetGetters()
+		opts.configure(o...)
+	}
+}
+
+// Clear clears all default or previously registered
+// methods and getters, giving a blank slate to build up
+// with the next options.
+//
+// Example Code:
+//
+//	New(Clear(), Methods(http.MethodPut), Headers("X-Custom-Header"))
+func Clear() Option {
+	return func(opts *options) {
+		opts.methods = opts.methods[0:0]
+		opts.resetGetters()
+	}
+}
+
+// Config is a read-only snapshot of the resolved options
+// of a method override wrapper, useful for debugging and testing.
+// See `NewWithConfig` package-level function for more.
+//
+// It describes the methods, the sources and the limits of the overrides only,
+// the callbacks, e.g. `OnReject`, and the rest of the behavior options are not included.
+type Config struct {
+	// Methods are the request methods that can be overridden.
+	Methods []string `json:"methods"`
+	// Headers are the header names to check for the method to override with.
+	Headers []string `json:"headers"`
+	// FormFields are the form field names to check for the method to override with.
+	FormFields []string `json:"formFields"`
+	// QueryParams are the url parameter names to check for the method to override with.
+	QueryParams []string `json:"queryParams"`
+	// SaveOriginalMethod reports whether the original method
+	// is saved on the request context.
+	SaveOriginalMethod bool `json:"saveOriginalMethod"`
+	// Sources are the kinds of the registered getters, by order.
+	Sources []SourceKind `json:"sources"`
+	// TargetMethods are the only methods to override with, empty if any method is allowed,
+	// see `AllowedTargetMethods`.
+	TargetMethods []string `json:"targetMethods"`
+	// FallbackMethod is the method to override with when the resolved one is unknown,
+	// see `FallbackMethod`.
+	FallbackMethod string `json:"fallbackMethod"`
+	// MaxMethodLength is the maximum length of a method to override with, 0 if unlimited,
+	// see `MaxMethodLength`.
+	MaxMethodLength int `json:"maxMethodLength"`
+	// TrustedProxies are the networks the header getters are trusted from,
+	// empty if any, see `TrustedProxies`.
+	TrustedProxies []string `json:"trustedProxies"`
+	// Throttle is the overrides limit per client, nil if unlimited,
+	// see `ThrottleOverrides`.
+	Throttle *ThrottleConfig `json:"throttle"`
+}
+
+// ThrottleConfig is the overrides limit of a `Config`.
+type ThrottleConfig struct {
+	// Max is the maximum number of overrides per client every "Per" duration.
+	Max int `json:"max"`
+	// Per is the duration of the limit, encoded in nanoseconds.
+	Per time.Duration `json:"per"`
+}
+
+// New returns a new method override wrapper
+// which can be registered on any HTTP server.
+//
+// Use this wrapper when you expecting clients
+// that do not support certain HTTP operations such as DELETE or PUT for security reasons.
+// This wrapper will accept a method, based on criteria, to override the POST method with.
+func New(opt ...Option) func(next http.Handler) http.Handler {
+	return newOptions(opt...).wrap
+}
+
+// NewFunc same as `New` but it wraps a single handler function directly.
+//
+// Example Code:
+//
+//	http.ListenAndServe(":8080", NewFunc(myHandlerFunc))
+func NewFunc(next http.HandlerFunc, opt ...Option) http.HandlerFunc {
+	return newOptions(opt...).wrap(next).ServeHTTP
+}
+
+// NewWithConfig same as `New` but it also returns
+// the resolved configuration, including the default values.
+func NewWithConfig(opt ...Option) (func(next http.Handler) http.Handler, Config) {
+	opts := newOptions(opt...)
+	return opts.wrap, opts.config()
+}
+
+// DescribeHandler returns a handler which responds with the JSON
+// of the resolved configuration of the given options, including the default values,
+// e.g. the overridable methods and the sources to check, see `Config`.
+// The options are resolved the same way as the `New` package-level function does,
+// mount it on the same options to verify the configuration of a running server.
+//
+// Example Code:
+//
+//	mux.Handle("/_methodoverride", DescribeHandler(opts...))
+func DescribeHandler(opt ...Option) http.Handler {
+	body, err := json.Marshal(newOptions(opt...).config())
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(body)
+	})
+}
+
+// NewWithCleanup same as `New` but it returns a "cleanup" function as well
+// which releases the resources the wrapper still holds,
+// e.g. the temporary files of the requests being served, see `SpillToDisk`.
+// Call it once the server is shut down.
+//
+// Calling "cleanup" is optional when the request bodies are buffered in memory,
+// the default behavior, and it is safe to call it more than once.
+func NewWithCleanup(opt ...Option) (mw func(next http.Handler) http.Handler, cleanup func()) {
+	opts := newOptions(opt...)
+	opts.spills = new(spillFiles)
+	return opts.wrap, opts.spills.removeAll
+}
+
+// DefaultOptions returns the default options
+// a new method override wrapper is seeded with:
+//
+//	Methods(http.MethodPost)
+//	Headers("X-HTTP-Method", "X-HTTP-Method-Override", "X-Method-Override")
+//	FormField("_method")
+//	Query("_method")
+//	MaxMethodLength(32)
+//
+// Use it along with `Clear` to check a custom getter before the default ones:
+//
+//	New(append([]Option{Clear(), Getter(myGetter)}, DefaultOptions()...)...)
+func DefaultOptions() []Option {
+	return []Option{
+		Methods(http.MethodPost),
 		Headers("X-HTTP-Method", "X-HTTP-Method-Override", "X-Method-Override"),
 		FormField("_method"),
 		Query("_method"),
-	)
+		MaxMethodLength(32),
+	}
+}
+
+// NewStrict same as `New` but it validates the resolved options
+// and reports an error instead of misbehaving at runtime, e.g. when
+// an empty method is registered, there is no getter
+// or an allowed target method is a dangerous one.
+func NewStrict(opt ...Option) (func(next http.Handler) http.Handler, error) {
+	opts := newOptions(opt...)
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return opts.wrap, nil
+}
+
+// Resolve returns the method that a method override wrapper,
+// created with the same options, would override the request method with,
+// or empty if the request would not be overridden.
+// It does not modify the request method or context,
+// e.g. to let frameworks decide what to do with the method.
+//
+// Note that getters may still read and reset the request body.
+func Resolve(w http.ResponseWriter, r *http.Request, opt ...Option) string {
+	opts := newOptions(opt...)
+	newMethod, _ := opts.overrideMethod(w, r, opts.originalMethod(r))
+	return newMethod
+}
+
+func newOptions(opt ...Option) *options {
+	opts := new(options)
+	opts.configure(DefaultOptions()...)
 	opts.configure(opt...)
+	opts.sortGetters()
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			originalMethod := strings.ToUpper(r.Method)
-			if opts.canOverride(originalMethod) {
-				newMethod := opts.get(w, r)
-				if newMethod != "" {
-					if opts.saveOriginalMethodContextKey != nil {
-						r = r.WithContext(stdContext.WithValue(r.Context(), opts.saveOriginalMethodContextKey, originalMethod))
-					}
-					r.Method = newMethod
-				}
+	return opts
+}
+
+func (o *options) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o.serveHTTP(w, r, next)
+	})
+}
+
+func (o *options) serveHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if o.enabled != nil && !o.enabled(r) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	if o.spillThreshold > 0 {
+		// the getters reset the body of this request.
+		defer o.removeSpill(r)
+	}
+
+	originalMethod := o.originalMethod(r)
+	if !o.isAllowedMethod(originalMethod) {
+		w.Header().Set("Allow", strings.Join(o.allowedMethods, ", "))
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	preflight := false
+	if o.corsRequestMethod && originalMethod == http.MethodOptions {
+		if method := strings.TrimSpace(r.Header.Get("Access-Control-Request-Method")); method != "" {
+			r = r.WithContext(stdContext.WithValue(r.Context(), corsRequestMethodContextKey{}, method))
+			preflight = true
+		}
+	}
+
+	if o.saveOriginalMethodHeader != "" && !isOverridden(r) {
+		// do not trust a client-sent value.
+		r.Header.Del(o.saveOriginalMethodHeader)
+	}
+
+	var (
+		newMethod string
+		m         match
+	)
+	if !preflight {
+		newMethod, m = o.overrideMethod(w, r, originalMethod)
+	}
+
+	if o.cacheParsedForm {
+		if _, cached := FormFromContext(r); !cached {
+			if form, found := cachedForm(r); found {
+				r = r.WithContext(stdContext.WithValue(r.Context(), parsedFormContextKey{}, form))
 			}
+		}
+	}
 
-			next.ServeHTTP(w, r)
-		})
+	if o.spills != nil {
+		if f := spilledFile(r); f != nil {
+			o.spills.add(f)
+		}
+	}
+
+	if newMethod != "" && o.dryRun {
+		o.notifyOverride(r, originalMethod, newMethod)
+	} else if newMethod != "" {
+		if o.routeChecker != nil {
+			if allowed, allow := o.routeChecker(r, newMethod); !allowed {
+				w.Header().Set("Allow", strings.Join(allow, ", "))
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+		}
+
+		// only the applied overrides count against the limit.
+		if o.throttle != nil && !o.throttle.allow(r) {
+			o.notifyReject(originalMethod, newMethod, ReasonThrottled)
+		} else {
+			r = o.override(w, r, originalMethod, newMethod, m)
+			o.notifyOverride(r, originalMethod, newMethod)
+		}
+	}
+
+	if o.saveResolvedMethodContextKey != nil {
+		r = r.WithContext(stdContext.WithValue(r.Context(), o.saveResolvedMethodContextKey, r.Method))
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// originalMethod returns the normalized request method, see `NormalizeMethod`.
+func (o *options) originalMethod(r *http.Request) string {
+	if o.normalizeMethod != nil {
+		return o.normalizeMethod(r.Method)
+	}
+
+	return strings.ToUpper(strings.TrimSpace(r.Method))
+}
+
+func (o *options) notifyOverride(r *http.Request, originalMethod, newMethod string) {
+	if o.overrideHandler != nil {
+		o.overrideHandler(&OverrideEvent{Request: r, Original: originalMethod, Method: newMethod, DryRun: o.dryRun})
+	}
+}
+
+func (o *options) notifyReject(originalMethod, newMethod, reason string) {
+	if o.rejectHandler != nil {
+		o.rejectHandler(&OverrideError{Original: originalMethod, Attempted: newMethod, Reason: reason})
+	}
+}
+
+// overrideMethod returns the accepted method to override the "originalMethod" with
+// or empty if the request should not be overridden.
+func (o *options) overrideMethod(w http.ResponseWriter, r *http.Request, originalMethod string) (string, match) {
+	newMethod, m := o.resolve(w, r, originalMethod)
+	if newMethod != "" && o.fallbackMethod != "" && !o.isKnownMethod(newMethod) {
+		newMethod = o.fallbackMethod
+	}
+
+	if newMethod == "" {
+		return "", match{}
+	}
+
+	reason := o.canOverrideTo(newMethod)
+	if reason == "" && !o.authorize(r, originalMethod, newMethod) {
+		reason = ReasonNotAllowed
+	}
+
+	if reason != "" {
+		o.notifyReject(originalMethod, newMethod, reason)
+		return "", match{}
+	}
+
+	if o.normalizeHeadToGet && newMethod == http.MethodHead {
+		newMethod = http.MethodGet
+	}
+
+	return newMethod, m
+}
+
+// override returns a copy of the request with its method overridden.
+func (o *options) override(w http.ResponseWriter, r *http.Request, originalMethod, newMethod string, m match) *http.Request {
+	ctx := stdContext.WithValue(r.Context(), overriddenContextKey{}, struct{}{})
+	if o.saveOriginalMethodContextKey != nil {
+		ctx = stdContext.WithValue(ctx, o.saveOriginalMethodContextKey, originalMethod)
+	}
+	if o.markOverriddenContextKey != nil {
+		ctx = stdContext.WithValue(ctx, o.markOverriddenContextKey, true)
+	}
+	if o.saveOverrideSourceContextKey != nil && m.source != nil {
+		ctx = stdContext.WithValue(ctx, o.saveOverrideSourceContextKey, m.source.String(r))
+	}
+	r = r.WithContext(ctx)
+	r.Method = newMethod
+
+	if m.path != "" {
+		u := *r.URL
+		u.Path, u.RawPath = m.path, ""
+		r.URL = &u
+	}
+
+	if o.saveOriginalMethodHeader != "" {
+		r.Header.Set(o.saveOriginalMethodHeader, originalMethod)
+	}
+
+	if o.clearBodyForBodyless && isBodylessMethod(newMethod) {
+		r.Body = http.NoBody
+		r.ContentLength = 0
+	}
+
+	if o.clearFormContentType && m.source != nil && m.source.kind == SourceForm && isBodylessMethod(newMethod) {
+		r.Header.Del("Content-Type")
+	}
+
+	if o.echoHeader != "" {
+		w.Header().Set(o.echoHeader, originalMethod+"->"+newMethod)
 	}
+
+	return r
 }
@@ -1,14 +1,25 @@
 package methodoverride
 
 import (
+	"bytes"
+	stdContext "context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"net/url"
+	"os"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestMethodOverride(t *testing.T) {
@@ -83,8 +94,2515 @@ func TestMethodOverride(t *testing.T) {
 		statusCode(http.StatusOK).bodyEq(expectedDelResponse)
 }
 
+func TestAcceptParam(t *testing.T) {
+	mo := New(Only(AcceptParam("method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("Accept", "application/vnd.api+json; method=delete")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("Accept", "text/html, application/vnd.api+json; method=put")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("Accept", "text/html"), withHeader("Accept", "application/json; method=patch")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+	expect(t, http.MethodPost, srv.URL, withHeader("Accept", "application/json; method")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("Accept", "application/json")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestMaxBodyScan(t *testing.T) {
+	mo := New(MaxBodyScan(1024))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "%s%d", r.Method, len(b))
+	})))
+	defer srv.Close()
+
+	small := "_method=DELETE"
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", small)).
+		statusCode(http.StatusOK).bodyEq(fmt.Sprintf("%s%d", http.MethodDelete, len(small)))
+
+	large := "_method=DELETE&data=" + strings.Repeat("a", 1<<20)
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", large)).
+		statusCode(http.StatusOK).bodyEq(fmt.Sprintf("%s%d", http.MethodPost, len(large)))
+}
+
+func TestNewWithConfig(t *testing.T) {
+	_, config := NewWithConfig()
+
+	expected := Config{
+		Methods:         []string{http.MethodPost},
+		Headers:         []string{"X-HTTP-Method", "X-HTTP-Method-Override", "X-Method-Override"},
+		FormFields:      []string{"_method"},
+		QueryParams:     []string{"_method"},
+		Sources:         []SourceKind{SourceHeader, SourceForm, SourceQuery},
+		TargetMethods:   []string{},
+		MaxMethodLength: 32,
+		TrustedProxies:  []string{},
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("expected config: %#+v but got %#+v", expected, config)
+	}
+
+	_, config = NewWithConfig(Methods("put"), SaveOriginalMethod("_originalMethod"), Only(Headers("X-Custom-Header")))
+
+	expected = Config{
+		Methods:            []string{http.MethodPost, http.MethodPut},
+		Headers:            []string{"X-Custom-Header"},
+		FormFields:         []string{},
+		QueryParams:        []string{},
+		SaveOriginalMethod: true,
+		Sources:            []SourceKind{SourceHeader},
+		TargetMethods:      []string{},
+		MaxMethodLength:    32,
+		TrustedProxies:     []string{},
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("expected config: %#+v but got %#+v", expected, config)
+	}
+}
+
+func TestDescribeHandler(t *testing.T) {
+	srv := httptest.NewServer(DescribeHandler())
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL).
+		statusCode(http.StatusOK).
+		headerEq("Content-Type", "application/json; charset=utf-8").
+		bodyEq(`{"methods":["POST"],"headers":["X-HTTP-Method","X-HTTP-Method-Override","X-Method-Override"],` +
+			`"formFields":["_method"],"queryParams":["_method"],"saveOriginalMethod":false,"sources":["header","form","query"],` +
+			`"targetMethods":[],"fallbackMethod":"","maxMethodLength":32,"trustedProxies":[],"throttle":null}`)
+
+	srv = httptest.NewServer(DescribeHandler(Methods(http.MethodPut), Only(FormField("_verb"))))
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL).
+		statusCode(http.StatusOK).
+		bodyEq(`{"methods":["POST","PUT"],"headers":[],"formFields":["_verb"],"queryParams":[],"saveOriginalMethod":false,"sources":["form"],` +
+			`"targetMethods":[],"fallbackMethod":"","maxMethodLength":32,"trustedProxies":[],"throttle":null}`)
+
+	srv = httptest.NewServer(DescribeHandler(Only(), AllowedTargetMethods(http.MethodDelete), FallbackMethod(http.MethodGet),
+		TrustedProxies("10.0.0.0/8"), ThrottleOverrides(10, time.Second, nil)))
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL).
+		statusCode(http.StatusOK).
+		bodyEq(`{"methods":["POST"],"headers":[],"formFields":[],"queryParams":[],"saveOriginalMethod":false,"sources":[],` +
+			`"targetMethods":["DELETE"],"fallbackMethod":"GET","maxMethodLength":32,"trustedProxies":["10.0.0.0/8"],` +
+			`"throttle":{"max":10,"per":1000000000}}`)
+}
+
+func TestEnabled(t *testing.T) {
+	var enabled int32 // atomic, the handler runs on the server goroutines.
+	mo := New(Enabled(func(r *http.Request) bool {
+		return atomic.LoadInt32(&enabled) == 1
+	}), RejectMethodsNotIn(http.MethodPost))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("POST _method=DELETE")
+	expect(t, http.MethodGet, srv.URL).
+		statusCode(http.StatusOK).bodyEq("GET ")
+
+	atomic.StoreInt32(&enabled, 1)
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE _method=DELETE")
+	expect(t, http.MethodGet, srv.URL).
+		statusCode(http.StatusMethodNotAllowed)
+}
+
+func TestRequireSecret(t *testing.T) {
+	mo := New(RequireSecret("X-Override-Secret", "s3cr3t"))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", http.MethodDelete), withHeader("X-Override-Secret", "s3cr3t")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", http.MethodDelete), withHeader("X-Override-Secret", "invalid")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	srv = httptest.NewServer(New(RequireSecret("X-Override-Secret", ""))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", http.MethodDelete), withHeader("X-Override-Secret", "")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestClear(t *testing.T) {
+	mo := New(Clear(), Methods(http.MethodPut), Headers("X-Custom-Header"))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPut, srv.URL, withHeader("X-Custom-Header", http.MethodPatch)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodPatch)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPut, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPut, srv.URL, withQuery("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+}
+
+func TestIdempotentOnly(t *testing.T) {
+	srv := httptest.NewServer(New(IdempotentOnly())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPatch)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	srv = httptest.NewServer(New(IdempotentOnly("put"))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestGetterE(t *testing.T) {
+	var errs []error
+	mo := New(Only(
+		GetterE(func(w http.ResponseWriter, r *http.Request) (string, error) {
+			return "", errors.New("decode failed")
+		}, func(err error) {
+			errs = append(errs, err)
+		}),
+		Headers("X-HTTP-Method"),
+	))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+	if len(errs) != 1 || errs[0].Error() != "decode failed" {
+		t.Fatalf("expected a single decode error but got: %v", errs)
+	}
+}
+
+func BenchmarkMethodOverride(b *testing.B) {
+	h := New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	b.Run("GET", func(b *testing.B) {
+		benchmarkRequest(b, h, httptest.NewRequest(http.MethodGet, "/path", nil))
+	})
+
+	b.Run("EmptyPOST", func(b *testing.B) {
+		benchmarkRequest(b, h, httptest.NewRequest(http.MethodPost, "/path", nil))
+	})
+
+	b.Run("HeaderOverride", func(b *testing.B) {
+		r := httptest.NewRequest(http.MethodPost, "/path", nil)
+		r.Header.Set("X-HTTP-Method", http.MethodDelete)
+		benchmarkRequest(b, h, r)
+	})
+
+	b.Run("LowercaseHeaderOverride", func(b *testing.B) {
+		r := httptest.NewRequest(http.MethodPost, "/path", nil)
+		r.Header.Set("X-HTTP-Method", "delete")
+		benchmarkRequest(b, h, r)
+	})
+
+	b.Run("NoOverridePOST", func(b *testing.B) {
+		benchmarkFormRequest(b, h, "name=value")
+	})
+
+	b.Run("FormOverride", func(b *testing.B) {
+		benchmarkFormRequest(b, h, "name=value&_method=DELETE")
+	})
+}
+
+func benchmarkFormRequest(b *testing.B, h http.Handler, form string) {
+	r := httptest.NewRequest(http.MethodPost, "/path", nil)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body := strings.NewReader(form)
+	r.ContentLength = body.Size()
+
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		body.Seek(0, io.SeekStart)
+		r.Body = ioutil.NopCloser(body)
+		r.Form, r.PostForm = nil, nil
+		h.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkUpperMethod(b *testing.B) {
+	for _, method := range []string{http.MethodDelete, "delete"} {
+		b.Run(method, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				upperMethod(method)
+			}
+		})
+	}
+}
+
+func TestUpperMethod(t *testing.T) {
+	tests := map[string]string{
+		http.MethodDelete: http.MethodDelete,
+		"delete":          http.MethodDelete,
+		"Patch":           http.MethodPatch,
+		"m-search":        "M-SEARCH",
+	}
+
+	for method, expected := range tests {
+		if got := upperMethod(method); expected != got {
+			t.Fatalf("%s: expected '%s' but got '%s'", method, expected, got)
+		}
+	}
+}
+
+func benchmarkRequest(b *testing.B, h http.Handler, r *http.Request) {
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for key := range w.Header() {
+			delete(w.Header(), key)
+		}
+
+		h.ServeHTTP(w, r)
+	}
+}
+
+func TestContextGetter(t *testing.T) {
+	type contextKey struct{}
+
+	mo := New(Only(ContextGetter(contextKey{})))
+	h := mo(http.HandlerFunc(writeMethod))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get("X-Resolved-Method"); v != "" {
+			r = r.WithContext(stdContext.WithValue(r.Context(), contextKey{}, v))
+		} else if r.Header.Get("X-Invalid-Value") != "" {
+			r = r.WithContext(stdContext.WithValue(r.Context(), contextKey{}, 42))
+		}
+
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Resolved-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Invalid-Value", "1")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestMethodMap(t *testing.T) {
+	mo := New(MethodMap(map[string]string{"put": "patch"}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPut, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+	// Getters are checked only for the registered methods.
+	expect(t, http.MethodPut, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	mo = New(Methods(http.MethodPut), MethodMap(map[string]string{http.MethodPut: http.MethodPatch}))
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPut, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPut, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+}
+
+func TestQueryFlag(t *testing.T) {
+	mo := New(Only(QueryFlag(http.MethodPut, http.MethodDelete)))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?PUT").
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL+"?id=42&delete").
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"?PUT=1").
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"?PATCH").
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestQueryTransform(t *testing.T) {
+	mo := New(Only(QueryTransform("action", func(v string) string {
+		if dot := strings.LastIndexByte(v, '.'); dot >= 0 {
+			return v[dot+1:]
+		}
+		return ""
+	})))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?action=user.delete").
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"?action=admin.user.put").
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL+"?action=delete").
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"?_method=delete").
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestMatrixParam(t *testing.T) {
+	writeMethodAndPath := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+
+	srv := httptest.NewServer(New(Only(MatrixParam("_method", false)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/path;_method=DELETE").
+		statusCode(http.StatusOK).bodyEq("DELETE /path;_method=DELETE")
+	expect(t, http.MethodPost, srv.URL+"/users;v=1/42;lang=en;_method=put").
+		statusCode(http.StatusOK).bodyEq("PUT /users;v=1/42;lang=en;_method=put")
+	expect(t, http.MethodPost, srv.URL+"/path;v=1").
+		statusCode(http.StatusOK).bodyEq("POST /path;v=1")
+
+	srv = httptest.NewServer(New(Only(MatrixParam("_method", true)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/users;v=1/42;_method=DELETE").
+		statusCode(http.StatusOK).bodyEq("DELETE /users/42")
+	expect(t, http.MethodPost, srv.URL+"/path;v=1").
+		statusCode(http.StatusOK).bodyEq("POST /path;v=1")
+
+	// the path is not stripped when the override is not applied.
+	for _, opt := range []Option{DryRun(), AllowedTargetMethods(http.MethodPut)} {
+		srv = httptest.NewServer(New(Only(MatrixParam("_method", true)), opt)(writeMethodAndPath))
+		defer srv.Close()
+
+		expect(t, http.MethodPost, srv.URL+"/a;_method=DELETE/b").
+			statusCode(http.StatusOK).bodyEq("POST /a;_method=DELETE/b")
+	}
+}
+
+func TestPreferParam(t *testing.T) {
+	mo := New(Only(PreferParam("method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("Prefer", "method=delete, respond-async")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("Vary", "Prefer")
+	expect(t, http.MethodPost, srv.URL, withHeader("Prefer", `respond-async, wait=10, Method="PUT"; strict`)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("Prefer", `return="a,method=put"`), withHeader("Prefer", "method=patch")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+	expect(t, http.MethodPost, srv.URL, withHeader("Prefer", "respond-async, methods=delete")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost).headerEq("Vary", "")
+}
+
+func TestPathPrefixVerb(t *testing.T) {
+	writeMethodAndPath := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+	mapping := map[string]string{"delete": http.MethodDelete, "update": http.MethodPut}
+
+	srv := httptest.NewServer(New(Only(PathPrefixVerb(mapping, false)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/delete/users/42").
+		statusCode(http.StatusOK).bodyEq("DELETE /delete/users/42")
+	expect(t, http.MethodPost, srv.URL+"/users/42").
+		statusCode(http.StatusOK).bodyEq("POST /users/42")
+	expect(t, http.MethodPost, srv.URL+"/").
+		statusCode(http.StatusOK).bodyEq("POST /")
+
+	srv = httptest.NewServer(New(Only(PathPrefixVerb(mapping, true)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/delete/users/42").
+		statusCode(http.StatusOK).bodyEq("DELETE /users/42")
+	expect(t, http.MethodPost, srv.URL+"//update").
+		statusCode(http.StatusOK).bodyEq("PUT /")
+	expect(t, http.MethodPost, srv.URL+"/users/delete").
+		statusCode(http.StatusOK).bodyEq("POST /users/delete")
+
+	// the path is not rewritten when the override is not applied.
+	srv = httptest.NewServer(New(Only(PathPrefixVerb(map[string]string{"trace": http.MethodTrace}, true)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/trace/users/42").
+		statusCode(http.StatusOK).bodyEq("POST /trace/users/42")
+
+	srv = httptest.NewServer(New(Only(PathPrefixVerb(mapping, true)), DryRun())(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/delete/users/42").
+		statusCode(http.StatusOK).bodyEq("POST /delete/users/42")
+}
+
+func TestNewFunc(t *testing.T) {
+	srv := httptest.NewServer(NewFunc(writeMethod, Only(Headers("X-Custom-Header"))))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestNoBodyRead(t *testing.T) {
+	mo := New(NoBodyRead())
+	h := mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %s", r.Method, b)
+	}))
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq("POST _method=DELETE")
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withBody("application/x-www-form-urlencoded", "data")).
+		statusCode(http.StatusOK).bodyEq("DELETE data")
+
+	// Already parsed form values are still checked.
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq("DELETE ")
+}
+
+func TestAllowReoverride(t *testing.T) {
+	methods := MethodMap(map[string]string{http.MethodPost: http.MethodPut, http.MethodPut: http.MethodPatch})
+
+	mo := New(methods)
+	srv := httptest.NewServer(mo(mo(http.HandlerFunc(writeMethod))))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+
+	mo = New(methods, AllowReoverride())
+	srv = httptest.NewServer(mo(mo(http.HandlerFunc(writeMethod))))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+}
+
+func TestAllowDangerousMethods(t *testing.T) {
+	srv := httptest.NewServer(New()(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", http.MethodTrace)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "connect")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	srv = httptest.NewServer(New(AllowDangerousMethods())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", http.MethodTrace)).
+		statusCode(http.StatusOK).bodyEq(http.MethodTrace)
+}
+
+func TestClearBodyForBodyless(t *testing.T) {
+	mo := New(ClearBodyForBodyless())
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %d %s", r.Method, r.ContentLength, b)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodGet), withBody("text/plain", "data")).
+		statusCode(http.StatusOK).bodyEq("GET 0 ")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut), withBody("text/plain", "data")).
+		statusCode(http.StatusOK).bodyEq("PUT 4 data")
+}
+
+func TestClearFormContentType(t *testing.T) {
+	mo := New(ClearFormContentType())
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.Header.Get("Content-Type"))
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE ")
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq("PUT application/x-www-form-urlencoded")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withFormField("name", "kataras")).
+		statusCode(http.StatusOK).bodyEq("DELETE application/x-www-form-urlencoded")
+}
+
+func TestHeaderDecoded(t *testing.T) {
+	mo := New(Only(HeaderDecoded("X-Grpc-Web-Method", func(v string) string {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}), Headers("X-HTTP-Method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Grpc-Web-Method", base64.StdEncoding.EncodeToString([]byte(http.MethodDelete)))).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("Vary", "X-Grpc-Web-Method")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Grpc-Web-Method", "!invalid"), withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Grpc-Web-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestHeadersCanonical(t *testing.T) {
+	mo := New(Only(Headers("x_http_method", "x-custom-header")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("Vary", "X-Http-Method")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut).headerEq("Vary", "X-Custom-Header")
+	expect(t, http.MethodPost, srv.URL, withHeader("X_HTTP_Method", http.MethodPatch)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch).headerEq("Vary", "X_http_method")
+
+	srv = httptest.NewServer(New(Only(Headers("X_Method")))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X_Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("Vary", "X_method")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut).headerEq("Vary", "X-Method")
+}
+
+func TestWebDAVMethods(t *testing.T) {
+	mo := New(WebDAVMethods(), AllowedTargetMethods(http.MethodDelete))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "propfind")).
+		statusCode(http.StatusOK).bodyEq("PROPFIND")
+	expect(t, http.MethodPost, srv.URL, withQuery("_method", "MKCOL")).
+		statusCode(http.StatusOK).bodyEq("MKCOL")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	// without a whitelist the standard methods can still be used.
+	mo = New(WebDAVMethods(), StrictMethods())
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "propfind")).
+		statusCode(http.StatusOK).bodyEq("PROPFIND")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "PURGE")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestCacheMethods(t *testing.T) {
+	mo := New(CacheMethods(), StrictMethods())
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PURGE" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Write([]byte("purged"))
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", "PURGE")).
+		statusCode(http.StatusOK).bodyEq("purged")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "ban")).
+		statusCode(http.StatusMethodNotAllowed)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusMethodNotAllowed)
+
+	// the standard methods can still be used, unless a whitelist is registered.
+	srv = httptest.NewServer(New(CacheMethods())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+	srv = httptest.NewServer(New(CacheMethods(), AllowedTargetMethods(http.MethodPut))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "purge")).
+		statusCode(http.StatusOK).bodyEq("PURGE")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestDryRun(t *testing.T) {
+	var events []OverrideEvent
+	onOverride := OnOverride(func(e *OverrideEvent) {
+		events = append(events, OverrideEvent{Original: e.Original, Method: e.Method, DryRun: e.DryRun})
+	})
+
+	srv := httptest.NewServer(New(DryRun(), onOverride)(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	if expected := []OverrideEvent{{Original: http.MethodPost, Method: http.MethodDelete, DryRun: true}}; !reflect.DeepEqual(expected, events) {
+		t.Fatalf("expected events: %#+v but got %#+v", expected, events)
+	}
+
+	events = nil
+	srv = httptest.NewServer(New(onOverride)(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+	if expected := []OverrideEvent{{Original: http.MethodPost, Method: http.MethodDelete}}; !reflect.DeepEqual(expected, events) {
+		t.Fatalf("expected events: %#+v but got %#+v", expected, events)
+	}
+}
+
+func TestRouteChecker(t *testing.T) {
+	mo := New(RouteChecker(func(r *http.Request, method string) (bool, []string) {
+		return method != http.MethodPatch, []string{http.MethodGet, http.MethodPost, http.MethodDelete}
+	}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("Allow", "")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPatch)).
+		statusCode(http.StatusMethodNotAllowed).bodyEq("").headerEq("Allow", "GET, POST, DELETE")
+	expect(t, http.MethodPatch, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+}
+
+func TestOnReject(t *testing.T) {
+	var rejected []OverrideError
+	mo := New(AllowedTargetMethods(http.MethodDelete, "DEL ETE", http.MethodTrace), OnReject(func(err *OverrideError) {
+		rejected = append(rejected, *err)
+	}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	tests := []struct {
+		method   string
+		expected OverrideError
+	}{
+		{"DEL ETE", OverrideError{Original: http.MethodPost, Attempted: "DEL ETE", Reason: ReasonInvalidToken}},
+		{"del(ete)", OverrideError{Original: http.MethodPost, Attempted: "DEL(ETE)", Reason: ReasonInvalidToken}},
+		{http.MethodPut, OverrideError{Original: http.MethodPost, Attempted: http.MethodPut, Reason: ReasonNotAllowed}},
+		{http.MethodTrace, OverrideError{Original: http.MethodPost, Attempted: http.MethodTrace, Reason: ReasonDangerousMethod}},
+	}
+
+	for i, tt := range tests {
+		rejected = nil
+		expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", tt.method)).
+			statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+		if len(rejected) != 1 || !reflect.DeepEqual(tt.expected, rejected[0]) {
+			t.Fatalf("[%d] expected rejection: %#+v but got %#+v", i, tt.expected, rejected)
+		}
+	}
+
+	rejected = nil
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	if len(rejected) != 0 {
+		t.Fatalf("expected no rejection but got %#+v", rejected)
+	}
+
+	err := &OverrideError{Original: http.MethodPost, Attempted: http.MethodPut, Reason: ReasonNotAllowed}
+	if expected, got := `methodoverride: override of POST with "PUT" rejected: not-allowed`, err.Error(); expected != got {
+		t.Fatalf("expected error: '%s' but got '%s'", expected, got)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var calls []string
+	mo := New(
+		Authorize(func(r *http.Request, original, target string) bool {
+			calls = append(calls, original+"->"+target)
+			return true
+		}),
+		Authorize(func(r *http.Request, original, target string) bool {
+			return target != http.MethodDelete
+		}),
+	)
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+
+	if expected := []string{"POST->DELETE", "POST->PUT"}; !reflect.DeepEqual(expected, calls) {
+		t.Fatalf("expected authorize calls: %v but got %v", expected, calls)
+	}
+}
+
+func TestPathParam(t *testing.T) {
+	writeMethodAndPath := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+
+	srv := httptest.NewServer(New(Only(PathParam(0, false)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/DELETE/users/42").
+		statusCode(http.StatusOK).bodyEq("DELETE /DELETE/users/42")
+	expect(t, http.MethodPost, srv.URL+"/").
+		statusCode(http.StatusOK).bodyEq("POST /")
+
+	srv = httptest.NewServer(New(Only(PathParam(0, true)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/DELETE/users/42").
+		statusCode(http.StatusOK).bodyEq("DELETE /users/42")
+	expect(t, http.MethodPost, srv.URL+"/put").
+		statusCode(http.StatusOK).bodyEq("PUT /")
+
+	srv = httptest.NewServer(New(Only(PathParam(-1, true)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/users/42/DELETE").
+		statusCode(http.StatusOK).bodyEq("DELETE /users/42")
+	expect(t, http.MethodPost, srv.URL+"/users/42/%50UT/").
+		statusCode(http.StatusOK).bodyEq("PUT /users/42/")
+
+	srv = httptest.NewServer(New(Only(PathParam(-3, false)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/users/42").
+		statusCode(http.StatusOK).bodyEq("POST /users/42")
+
+	// only known methods are used.
+	srv = httptest.NewServer(New(Only(PathParam(-1, true)), AllowedTargetMethods("PURGE"))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/users/42").
+		statusCode(http.StatusOK).bodyEq("POST /users/42")
+	expect(t, http.MethodPost, srv.URL+"/cache/purge").
+		statusCode(http.StatusOK).bodyEq("PURGE /cache")
+
+	// the path is not rewritten when the override is not applied.
+	deny := Authorize(func(r *http.Request, originalMethod, newMethod string) bool { return false })
+	for _, opt := range []Option{DryRun(), deny} {
+		srv = httptest.NewServer(New(Only(PathParam(-1, true)), opt)(writeMethodAndPath))
+		defer srv.Close()
+
+		expect(t, http.MethodPost, srv.URL+"/users/42/DELETE").
+			statusCode(http.StatusOK).bodyEq("POST /users/42/DELETE")
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/users/42/DELETE", nil)
+	if got := Resolve(httptest.NewRecorder(), r, Only(PathParam(-1, true))); got != http.MethodDelete {
+		t.Fatalf("expected resolved method: %s but got %s", http.MethodDelete, got)
+	}
+	if expected, got := "/users/42/DELETE", r.URL.Path; expected != got {
+		t.Fatalf("expected path: %s but got %s", expected, got)
+	}
+}
+
+func TestRichGetter(t *testing.T) {
+	writeMethodAndPath := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+
+	getter := func(w http.ResponseWriter, r *http.Request) (string, string) {
+		return r.Header.Get("X-Envelope-Method"), r.Header.Get("X-Envelope-Path")
+	}
+	mo := New(Only(RichGetter(getter)))
+
+	srv := httptest.NewServer(mo(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/envelope", withHeader("X-Envelope-Method", http.MethodDelete), withHeader("X-Envelope-Path", "/users/42")).
+		statusCode(http.StatusOK).bodyEq("DELETE /users/42")
+	expect(t, http.MethodPost, srv.URL+"/envelope", withHeader("X-Envelope-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq("PUT /envelope")
+	expect(t, http.MethodPost, srv.URL+"/envelope", withHeader("X-Envelope-Path", "/users/42")).
+		statusCode(http.StatusOK).bodyEq("POST /envelope")
+
+	// the path is not rewritten when the override is not applied.
+	for _, opt := range []Option{DryRun(), AllowedTargetMethods(http.MethodPut)} {
+		srv = httptest.NewServer(New(Only(RichGetter(getter)), opt)(writeMethodAndPath))
+		defer srv.Close()
+
+		expect(t, http.MethodPost, srv.URL+"/envelope", withHeader("X-Envelope-Method", http.MethodDelete), withHeader("X-Envelope-Path", "/users/42")).
+			statusCode(http.StatusOK).bodyEq("POST /envelope")
+	}
+
+	// it does not run concurrently with the other getters.
+	srv = httptest.NewServer(New(ConcurrentGetters(), Only(RichGetter(getter), PathParam(-1, true)))(writeMethodAndPath))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/envelope", withHeader("X-Envelope-Method", http.MethodDelete), withHeader("X-Envelope-Path", "/users/42")).
+		statusCode(http.StatusOK).bodyEq("DELETE /users/42")
+}
+
+func TestTrailer(t *testing.T) {
+	mo := New(Only(Trailer("X-HTTP-Method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %s", r.Method, b)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("text/plain", "data"), withTrailer("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE data")
+	expect(t, http.MethodPost, srv.URL, withBody("text/plain", "data"), withTrailer("X-Other", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("POST data")
+	expect(t, http.MethodPost, srv.URL, withBody("text/plain", "data")).
+		statusCode(http.StatusOK).bodyEq("POST data")
+
+	// the form is parsed from the body already read by the trailer getter.
+	mo = New(Only(Trailer("X-HTTP-Method"), FormField("_method")))
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %s", r.Method, b)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=PUT"), withTrailer("X-Other", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("PUT _method=PUT")
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=PUT"), withTrailer("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE _method=PUT")
+}
+
+func TestStrictMethods(t *testing.T) {
+	mo := New(StrictMethods("purge"))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "delete")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "DELET")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "PURGE")).
+		statusCode(http.StatusOK).bodyEq("PURGE")
+
+	srv = httptest.NewServer(New(StrictMethods(), WebDAVMethods())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "PROPFIND")).
+		statusCode(http.StatusOK).bodyEq("PROPFIND")
+}
+
+func TestDefaultOptions(t *testing.T) {
+	custom := Getter(func(w http.ResponseWriter, r *http.Request) string {
+		return r.Header.Get("X-Custom-Header")
+	})
+
+	mo, config := NewWithConfig(append([]Option{Clear(), custom}, DefaultOptions()...)...)
+	_, defaultConfig := NewWithConfig()
+	defaultConfig.Sources = append([]SourceKind{SourceCustom}, defaultConfig.Sources...)
+	if !reflect.DeepEqual(defaultConfig, config) {
+		t.Fatalf("expected config: %#+v but got %#+v", defaultConfig, config)
+	}
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodPut), withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func TestMaxMethodLength(t *testing.T) {
+	var reasons []string
+	mo := New(OnReject(func(err *OverrideError) {
+		reasons = append(reasons, err.Reason)
+	}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", strings.Repeat("A", 1000))).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	if expected := []string{ReasonTooLong}; !reflect.DeepEqual(expected, reasons) {
+		t.Fatalf("expected reasons: %v but got %v", expected, reasons)
+	}
+
+	mo = New(MaxMethodLength(4))
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+
+	mo = New(MaxMethodLength(0))
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", strings.Repeat("A", 1000))).
+		statusCode(http.StatusOK).bodyEq(strings.Repeat("A", 1000))
+}
+
+func TestSaveOverrideSource(t *testing.T) {
+	type sourceKey struct{}
+	mo := New(SaveOverrideSource(sourceKey{}), FormFields("_verb"), Getter(func(w http.ResponseWriter, r *http.Request) string {
+		return r.Header.Get("X-Custom-Header")
+	}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src, ok := OverrideSource(r, sourceKey{})
+		fmt.Fprintf(w, "%s %s %v", r.Method, src, ok)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE header:X-HTTP-Method true")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Method-Override", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE header:X-Method-Override true")
+	expect(t, http.MethodPost, srv.URL, withFormField("_verb", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq("PUT form:_verb true")
+	expect(t, http.MethodPost, srv.URL+"?_method=PATCH").
+		statusCode(http.StatusOK).bodyEq("PATCH query:_method true")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq("PUT custom true")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq("POST  false")
+}
+
+func TestSaveResolvedMethod(t *testing.T) {
+	type originalKey struct{}
+	type resolvedKey struct{}
+	mo := New(SaveOriginalMethod(originalKey{}), SaveResolvedMethod(resolvedKey{}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v %v", r.Context().Value(originalKey{}), r.Context().Value(resolvedKey{}))
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("POST DELETE")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq("<nil> POST")
+	expect(t, http.MethodDelete, srv.URL).
+		statusCode(http.StatusOK).bodyEq("<nil> DELETE")
+}
+
+func TestMarkOverridden(t *testing.T) {
+	type overriddenKey struct{}
+	mo := New(MarkOverridden(overriddenKey{}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %v", r.Method, WasOverridden(r, overriddenKey{}))
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE true")
+	expect(t, http.MethodDelete, srv.URL).
+		statusCode(http.StatusOK).bodyEq("DELETE false")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq("POST false")
+}
+
+func TestCORSRequestMethod(t *testing.T) {
+	mo := New(Methods(http.MethodOptions), CORSRequestMethod())
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := CORSRequestMethodFromContext(r)
+		fmt.Fprintf(w, "%s %s %v", r.Method, method, ok)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodOptions, srv.URL, withHeader("Access-Control-Request-Method", http.MethodDelete), withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq("OPTIONS DELETE true")
+	expect(t, http.MethodOptions, srv.URL).
+		statusCode(http.StatusOK).bodyEq("OPTIONS  false")
+	expect(t, http.MethodPost, srv.URL, withHeader("Access-Control-Request-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("POST  false")
+}
+
+func TestSaveOriginalMethodHeader(t *testing.T) {
+	const key = "_originalMethod"
+	mo := New(SaveOriginalMethodHeader("X-Original-Method"), SaveOriginalMethod(key))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		original, _ := r.Context().Value(key).(string)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("X-Original-Method"), original)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq("DELETE POST POST")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq("POST  ")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Original-Method", http.MethodGet)).
+		statusCode(http.StatusOK).bodyEq("POST  ")
+}
+
+func TestNormalizeHeadToGet(t *testing.T) {
+	const key = "_originalMethod"
+	mo := New(NormalizeHeadToGet(), SaveOriginalMethod(key))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %s", r.Method, r.Context().Value(key))
+	})
+
+	srv := httptest.NewServer(mo(mux))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodHead)).
+		statusCode(http.StatusOK).bodyEq("GET POST")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusMethodNotAllowed)
+}
+
+func TestNormalizeMethod(t *testing.T) {
+	tests := []struct {
+		opts     []Option
+		method   string
+		expected string
+	}{
+		{nil, " post ", http.MethodDelete},
+		{nil, "Post", http.MethodDelete},
+		{[]Option{NormalizeMethod(func(method string) string { return method })}, " post ", " post "},
+		{[]Option{NormalizeMethod(func(method string) string {
+			return strings.ToUpper(strings.Trim(method, " _"))
+		})}, "_post_", http.MethodDelete},
+	}
+
+	for i, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Method = tt.method
+		r.Header.Set("X-HTTP-Method", http.MethodDelete)
+
+		w := httptest.NewRecorder()
+		New(tt.opts...)(http.HandlerFunc(writeMethod)).ServeHTTP(w, r)
+		if got := w.Body.String(); tt.expected != got {
+			t.Fatalf("[%d] expected to receive '%s' but got '%s'", i, tt.expected, got)
+		}
+	}
+}
+
+func TestEchoHeader(t *testing.T) {
+	mo := New(EchoHeader("X-Method-Override-Applied"))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("X-Method-Override-Applied", "POST->DELETE")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost).headerEq("X-Method-Override-Applied", "")
+}
+
+func TestFormFields(t *testing.T) {
+	mo := New(Only(FormFields("_method", "__method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("__method", http.MethodPut), withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withFormField("__method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", "")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestRejectMethodsNotIn(t *testing.T) {
+	mo := New(RejectMethodsNotIn(http.MethodGet, http.MethodPost))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPatch, srv.URL).
+		statusCode(http.StatusMethodNotAllowed).bodyEq("").headerEq("Allow", "GET, POST")
+	expect(t, http.MethodGet, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodGet)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPatch)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+}
+
+func TestWhenPathPrefix(t *testing.T) {
+	mo := New(Only(Headers("X-HTTP-Method")), WhenPathPrefix("/api/", Query("_method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/api/x?_method=DELETE").
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"/static/x?_method=DELETE").
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"/static/x", withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func TestRequireAgreement(t *testing.T) {
+	mo := New(Only(RequireAgreement(Headers("X-HTTP-Method"), FormField("_method"))))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withFormField("_method", "delete")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withFormField("_method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestAddRule(t *testing.T) {
+	prefix := func(prefix string) func(string) bool {
+		return func(path string) bool { return strings.HasPrefix(path, prefix) }
+	}
+
+	mo := New(Only(),
+		AddRule(Rule{Match: prefix("/reports/")}),
+		AddRule(Rule{Match: prefix("/api/"), Getters: []Option{Headers("X-HTTP-Method")}}),
+		AddRule(Rule{Match: prefix("/"), Getters: []Option{FormField("_method")}}),
+	)
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/users/42", withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"/reports/1", withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"/api/users", withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL+"/api/users", withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"/users/42", withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestAllOf(t *testing.T) {
+	mo := New(Only(AllOf(Headers("X-HTTP-Method"), FormField("_method"), Query("_verb"))))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?_verb=DELETE", withHeader("X-HTTP-Method", http.MethodDelete), withFormField("_method", "delete")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"?_verb=PUT", withHeader("X-HTTP-Method", http.MethodDelete), withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestAnyOf(t *testing.T) {
+	mo, config := NewWithConfig(Only(AllOf(AnyOf(Headers("X-HTTP-Method"), Query("_method")), FormField("_method"))))
+	if expected := []SourceKind{SourceAgreement}; !reflect.DeepEqual(expected, config.Sources) {
+		t.Fatalf("expected sources: %v but got %v", expected, config.Sources)
+	}
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"?_method=PUT", withFormField("_method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL+"?_method=PUT").
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	_, config = NewWithConfig(Only(AnyOf(Headers("X-HTTP-Method"), Query("_method")), FormField("_method")))
+	if expected := []SourceKind{SourceGroup, SourceForm}; !reflect.DeepEqual(expected, config.Sources) {
+		t.Fatalf("expected sources: %v but got %v", expected, config.Sources)
+	}
+}
+
+func TestWhenPath(t *testing.T) {
+	mo := New(Only(),
+		WhenPath(regexp.MustCompile(`^/v1/`).MatchString, FormField("_method")),
+		WhenPath(func(path string) bool { return strings.HasPrefix(path, "/v2/") }, FormField("_verb")),
+	)
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/v1/users", withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"/v1/users", withFormField("_verb", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"/v2/users", withFormField("_verb", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL+"/v2/users", withFormField("_method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"/v3/users", withFormField("_method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestWhenHost(t *testing.T) {
+	mo := New(Only(),
+		WhenHost("*.api.example.com", Headers("X-HTTP-Method")),
+		WhenHost("example.com", FormField("_method")),
+	)
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	withHost := func(host string) func(*http.Request) {
+		return func(r *http.Request) { r.Host = host }
+	}
+
+	expect(t, http.MethodPost, srv.URL, withHost("tenant.api.example.com"), withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHost("Tenant.API.example.com:8080"), withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHost("api.example.com"), withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHost("example.com"), withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHost("example.com"), withFormField("_method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHost("other.com"), withFormField("_method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestNewStrict(t *testing.T) {
+	if _, err := NewStrict(); err != nil {
+		t.Fatalf("expected default options to be valid but got: %v", err)
+	}
+
+	tests := []struct {
+		opts     []Option
+		expected string
+	}{
+		{[]Option{Methods("")}, "methodoverride: empty method"},
+		{[]Option{Only()}, "methodoverride: no getters"},
+		{[]Option{AllowedTargetMethods(http.MethodTrace)}, "methodoverride: target method TRACE requires AllowDangerousMethods"},
+		{[]Option{RejectMethodsNotIn(http.MethodGet)}, "methodoverride: method POST can be overridden but it is rejected"},
+		{[]Option{MaxGetters(4), Query("m"), Headers("X-Method")}, "methodoverride: 5 getters registered, more than the maximum of 4"},
+		{[]Option{RequireSecret("X-Override-Secret", "")}, "methodoverride: empty secret for header X-Override-Secret"},
+		{[]Option{ThrottleOverrides(0, time.Minute, nil)}, "methodoverride: invalid throttle max 0"},
+		{[]Option{ThrottleOverrides(10, 0, nil)}, "methodoverride: invalid throttle duration 0s"},
+	}
+
+	for i, tt := range tests {
+		mo, err := NewStrict(tt.opts...)
+		if err == nil || err.Error() != tt.expected {
+			t.Fatalf("[%d] expected error: '%s' but got: %v", i, tt.expected, err)
+		}
+
+		if mo != nil {
+			t.Fatalf("[%d] expected a nil wrapper on error", i)
+		}
+	}
+}
+
+func TestMaxGetters(t *testing.T) {
+	if _, err := NewStrict(MaxGetters(3)); err != nil {
+		t.Fatalf("expected default options to be valid but got: %v", err)
+	}
+
+	if _, err := NewStrict(MaxGetters(1), Only(Headers("X-Custom-Header"), Query("_method"))); err == nil {
+		t.Fatalf("expected an error for more getters than the maximum")
+	}
+
+	mo := New(MaxGetters(1), Only(Headers("X-Custom-Header"), Query("_method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"?_method=PUT").
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+}
+
+func TestStopOnEmpty(t *testing.T) {
+	mo := New(Only(Query("_method"), Headers("X-HTTP-Method")), StopOnEmpty())
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?_method=", withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"?other=", withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"?_method=put", withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+
+	mo = New(Only(Query("_method"), Headers("X-HTTP-Method")))
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?_method=", withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func TestGetter2(t *testing.T) {
+	mo := New(Only(
+		Getter2(func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			values, present := r.Header["X-Custom-Header"]
+			if !present {
+				return "", false
+			}
+
+			return values[0], true
+		}),
+		Query("_method"),
+	), StopOnEmpty())
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withHeader("X-Custom-Header", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withHeader("X-Custom-Header", "")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE").
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func ExampleContextGetter() {
+	type methodClaimKey struct{}
+
+	// A fake authentication middleware which stashes
+	// the "method" claim of an already verified bearer token.
+	tokens := map[string]map[string]interface{}{
+		"batch-client-token": {"sub": "batch", "method": "DELETE"},
+	}
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+			if method, ok := claims["method"].(string); ok {
+				r = r.WithContext(stdContext.WithValue(r.Context(), methodClaimKey{}, method))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	mo := New(Only(ContextGetter(methodClaimKey{})))
+	handler := auth(mo(http.HandlerFunc(writeMethod)))
+
+	for _, token := range []string{"batch-client-token", "other-token"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/path", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(w, r)
+
+		fmt.Println(w.Body.String())
+	}
+
+	// Output:
+	// DELETE
+	// POST
+}
+
+func TestJSONPath(t *testing.T) {
+	mo := New(Only(JSONPath("meta.method"), JSONField("_method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	})))
+	defer srv.Close()
+
+	tests := []struct {
+		contentType string
+		body        string
+		expected    string
+	}{
+		{"application/json", `{"meta":{"method":"DELETE"},"id":42}`, http.MethodDelete},
+		{"application/vnd.api+json; charset=utf-8", `{"meta":{"method":"put"}}`, http.MethodPut},
+		{"application/json", `{"_method":"PATCH"}`, http.MethodPatch},
+		{"application/json", `{"meta":{"verb":"DELETE"}}`, http.MethodPost},
+		{"application/json", `{"meta":"DELETE"}`, http.MethodPost},
+		{"application/json", `{"meta":{"method":42}}`, http.MethodPost},
+		{"application/json", `[{"meta":{"method":"DELETE"}}]`, http.MethodPost},
+		{"application/json", `{"meta":`, http.MethodPost},
+		{"text/plain", `{"meta":{"method":"DELETE"}}`, http.MethodPost},
+	}
+
+	for _, tt := range tests {
+		expect(t, http.MethodPost, srv.URL, withBody(tt.contentType, tt.body)).
+			statusCode(http.StatusOK).bodyEq(tt.expected + " " + tt.body)
+	}
+
+	// after the default form getter which read the body.
+	srv = httptest.NewServer(New(JSONPath("meta.method"))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/json", `{"meta":{"method":"DELETE"}}`)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func TestMultipartField(t *testing.T) {
+	mo := New(Only(MultipartField("_method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withMultipartForm("_method", http.MethodDelete, "name", "value")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withMultipartForm("name", "value")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestFieldMap(t *testing.T) {
+	mo := New(FieldMap("_m", map[string]string{"1": http.MethodPut, "3": http.MethodDelete}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_m", "3")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"?_m=1").
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withFormField("_m", "9"), withHeader("X-HTTP-Method", http.MethodPatch)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPatch)
+	expect(t, http.MethodPost, srv.URL, withFormField("_m", "9")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	type sourceKey struct{}
+	mo = New(Only(FieldMap("_m", map[string]string{"3": http.MethodDelete})), SaveOverrideSource(sourceKey{}), ClearFormContentType())
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src, _ := OverrideSource(r, sourceKey{})
+		fmt.Fprintf(w, "%s %s %s", r.Method, src, r.Header.Get("Content-Type"))
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?_m=3", withBody("application/json", "{}")).
+		statusCode(http.StatusOK).bodyEq("DELETE query:_m application/json")
+	expect(t, http.MethodPost, srv.URL, withFormField("_m", "3")).
+		statusCode(http.StatusOK).bodyEq("DELETE form:_m ")
+}
+
+func TestFormParser(t *testing.T) {
+	// parses "text/plain" bodies of key=value lines.
+	textPlain := func(r *http.Request) (map[string][]string, bool) {
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "text/plain") {
+			return nil, false
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, false
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		form := make(map[string][]string)
+		for _, line := range strings.Split(string(body), "\n") {
+			if eq := strings.IndexByte(line, '='); eq > 0 {
+				key := strings.TrimSpace(line[:eq])
+				form[key] = append(form[key], strings.TrimSpace(line[eq+1:]))
+			}
+		}
+
+		return form, len(form) > 0
+	}
+
+	mo := New(Only(FormField("_method")), FormParser(textPlain))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("text/plain", "name = value\n_method = DELETE")).
+		statusCode(http.StatusOK).bodyEq("DELETE name = value\n_method = DELETE")
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq("POST _method=DELETE")
+}
+
+func TestParsedForm(t *testing.T) {
+	mo := New(Only(FormField("_method"), Getter(func(w http.ResponseWriter, r *http.Request) string {
+		if form, ok := ParsedForm(r); ok && len(form["_verb"]) > 0 {
+			return form["_verb"][0]
+		}
+
+		return ""
+	})))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		form, _ := ParsedForm(r)
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, form["name"], body)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_verb=PUT&name=value")).
+		statusCode(http.StatusOK).bodyEq("PUT [value] _verb=PUT&name=value")
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "name=value")).
+		statusCode(http.StatusOK).bodyEq("POST [value] name=value")
+}
+
+func TestCacheParsedForm(t *testing.T) {
+	first := New(Methods(http.MethodDelete), CacheParsedForm())
+	second := New(Methods(http.MethodDelete), Only(FormField("_verb")), CacheParsedForm(), AllowReoverride())
+
+	// a middleware which passes a copy of the request without the parsed form and the body.
+	copyRequest := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.Clone(r.Context())
+			r.Form, r.PostForm, r.Body = nil, nil, http.NoBody
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	srv := httptest.NewServer(first(copyRequest(second(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		form, _ := FormFromContext(r)
+		fmt.Fprintf(w, "%s %s", r.Method, form["name"])
+	})))))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=DELETE&_verb=PUT&name=value")).
+		statusCode(http.StatusOK).bodyEq("PUT [value]")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq("POST []")
+}
+
+func TestFormBodyReadOnce(t *testing.T) {
+	var bodies []io.ReadCloser
+	record := Getter(func(w http.ResponseWriter, r *http.Request) string {
+		bodies = append(bodies, r.Body)
+		return ""
+	})
+
+	mo := New(Only(FormField("_a"), record, FormField("_b"), record, Trailer("X-Method")))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	}))
+
+	// an empty form, all form getters are checked.
+	const payload = "&&"
+	body := &countingReader{Reader: strings.NewReader(payload)}
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Trailer = http.Header{"X-Method": nil}
+	r.Body = ioutil.NopCloser(body)
+	r.ContentLength = int64(len(payload))
+
+	w := httptest.NewRecorder()
+	mo.ServeHTTP(w, r)
+	if expected, got := "POST "+payload, w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+
+	if expected, got := len(payload), body.n; expected != got {
+		t.Fatalf("expected %d body bytes to be read but got %d", expected, got)
+	}
+
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Fatalf("expected the body to be read and reset once")
+	}
+}
+
+func TestFormFieldMaxLen(t *testing.T) {
+	mo := New(FormFieldMaxLen(32), Only(FormField("_method"), Headers("X-HTTP-Method")))(http.HandlerFunc(writeMethod))
+
+	for _, tt := range []struct {
+		contentLength int64
+		read          bool
+		expected      string
+	}{
+		{-1, false, http.MethodPut},
+		{14, true, http.MethodDelete},
+		{32, true, http.MethodDelete},
+		{1 << 20, false, http.MethodPut},
+	} {
+		const payload = "_method=DELETE"
+		body := &countingReader{Reader: strings.NewReader(payload)}
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("X-HTTP-Method", http.MethodPut)
+		r.Body = ioutil.NopCloser(body)
+		r.ContentLength = tt.contentLength
+
+		w := httptest.NewRecorder()
+		mo.ServeHTTP(w, r)
+		if got := w.Body.String(); tt.expected != got {
+			t.Fatalf("[%d] expected to receive '%s' but got '%s'", tt.contentLength, tt.expected, got)
+		}
+
+		if read := body.n > 0; tt.read != read {
+			t.Fatalf("[%d] expected body read: %v but got %v", tt.contentLength, tt.read, read)
+		}
+	}
+}
+
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestSpillToDisk(t *testing.T) {
+	var spilled string
+	mo := New(SpillToDisk(16))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := r.Body.(scannedBody); ok && body.file != nil {
+			spilled = body.file.Name()
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	}))
+
+	for _, tt := range []struct {
+		body  string
+		spill bool
+	}{
+		{"_method=DELETE", false},
+		{"name=" + strings.Repeat("a", 64) + "&_method=DELETE", true},
+	} {
+		spilled = ""
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		mo.ServeHTTP(w, r)
+		if expected, got := "DELETE "+tt.body, w.Body.String(); expected != got {
+			t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+		}
+
+		if tt.spill != (spilled != "") {
+			t.Fatalf("expected spill to disk: %v", tt.spill)
+		}
+
+		if spilled != "" {
+			if _, err := os.Stat(spilled); !os.IsNotExist(err) {
+				t.Fatalf("expected the temporary file %s to be removed but got: %v", spilled, err)
+			}
+		}
+	}
+
+	// larger than the maximum body scan, the body is put back as it is.
+	srv := httptest.NewServer(New(SpillToDisk(16), MaxBodyScan(32))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %d", r.Method, len(body))
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=DELETE&name="+strings.Repeat("a", 64))).
+		statusCode(http.StatusOK).bodyEq("POST 84")
+}
+
+func TestNewWithCleanup(t *testing.T) {
+	mo, cleanup := NewWithCleanup()
+	cleanup() // no resources.
+	cleanup()
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+	var spilled string
+	mo, cleanup = NewWithCleanup(SpillToDisk(4))
+	h := mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spilled = spilledFile(r).Name()
+		cleanup() // e.g. on a shutdown while the request is still being served.
+		w.Write([]byte(r.Method))
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("_method=DELETE"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if expected, got := http.MethodDelete, w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+
+	if _, err := os.Stat(spilled); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary file %s to be removed but got: %v", spilled, err)
+	}
+	cleanup()
+}
+
+func TestOnBodyError(t *testing.T) {
+	errRead := errors.New("connection reset")
+
+	var got error
+	mo := New(OnBodyError(func(r *http.Request, err error) {
+		got = err
+	}))(http.HandlerFunc(writeMethod))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Body = ioutil.NopCloser(io.MultiReader(strings.NewReader("_method=DEL"), errReader{errRead}))
+	r.ContentLength = -1
+
+	w := httptest.NewRecorder()
+	mo.ServeHTTP(w, r)
+	if expected, body := http.MethodPost, w.Body.String(); expected != body {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, body)
+	}
+	if got != errRead {
+		t.Fatalf("expected body error: %v but got %v", errRead, got)
+	}
+
+	got = nil
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("_method=DELETE"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w = httptest.NewRecorder()
+	mo.ServeHTTP(w, r)
+	if expected, body := http.MethodDelete, w.Body.String(); expected != body {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, body)
+	}
+	if got != nil {
+		t.Fatalf("expected no body error but got %v", got)
+	}
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestFormFieldMultipart(t *testing.T) {
+	srv := httptest.NewServer(New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(postMaxMemory)
+		fmt.Fprintf(w, "%s %s", r.Method, r.FormValue("name"))
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withMultipartForm("_method", http.MethodDelete, "name", "value")).
+		statusCode(http.StatusOK).bodyEq("DELETE value")
+	expect(t, http.MethodPost, srv.URL, withMultipartForm("name", "value")).
+		statusCode(http.StatusOK).bodyEq("POST value")
+}
+
+func TestThrottleOverrides(t *testing.T) {
+	var rejected []string
+	mo := New(ThrottleOverrides(2, time.Hour, func(r *http.Request) string {
+		return r.Header.Get("X-Client")
+	}), OnReject(func(err *OverrideError) {
+		rejected = append(rejected, err.Reason)
+	}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("X-Client", "a")).
+			statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	}
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("X-Client", "a")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Client", "b")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("X-Client", "b")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+	if expected := []string{ReasonThrottled}; !reflect.DeepEqual(expected, rejected) {
+		t.Fatalf("expected rejections: %v but got %v", expected, rejected)
+	}
+
+	// dry runs are not applied, they do not count against the limit.
+	rejected, dryRun := nil, 0
+	mo = New(ThrottleOverrides(1, time.Hour, nil), DryRun(), OnOverride(func(evt *OverrideEvent) {
+		dryRun++
+	}), OnReject(func(err *OverrideError) {
+		rejected = append(rejected, err.Reason)
+	}))
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+			statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	}
+
+	if expected := 3; expected != dryRun || len(rejected) > 0 {
+		t.Fatalf("expected %d dry runs and no rejections but got %d and %v", expected, dryRun, rejected)
+	}
+
+	// neither the overrides rejected by the route checker.
+	mo = New(ThrottleOverrides(1, time.Hour, nil), RouteChecker(func(r *http.Request, method string) (bool, []string) {
+		return method != http.MethodDelete, []string{http.MethodPost, http.MethodPut}
+	}))
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+			statusCode(http.StatusMethodNotAllowed)
+	}
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestRequireIdempotencyKeyFor(t *testing.T) {
+	mo := New(RequireIdempotencyKeyFor("delete"))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("Idempotency-Key", "8e03978e")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+}
+
+func TestRequireContentType(t *testing.T) {
+	mo := New(RequireContentType("application/x-www-form-urlencoded"))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withBody("application/x-www-form-urlencoded; charset=utf-8", "")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withBody("application/json", "{}")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE").
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestGetterWithPriority(t *testing.T) {
+	custom := func(method string) GetterFunc {
+		return func(w http.ResponseWriter, r *http.Request) string {
+			if r.URL.Query().Get("custom") == "" {
+				return ""
+			}
+			return method
+		}
+	}
+
+	mo, config := NewWithConfig(
+		Getter(custom(http.MethodPatch)),
+		GetterWithPriority(custom(http.MethodPut), 10),
+		GetterWithPriority(custom(http.MethodDelete), 10),
+		GetterWithPriority(custom(http.MethodGet), -1),
+	)
+
+	if expected := []SourceKind{SourceCustom, SourceCustom, SourceHeader, SourceForm, SourceQuery, SourceCustom, SourceCustom}; !reflect.DeepEqual(expected, config.Sources) {
+		t.Fatalf("expected sources: %v but got %v", expected, config.Sources)
+	}
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?custom=1", withHeader("X-HTTP-Method", http.MethodOptions)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodOptions)).
+		statusCode(http.StatusOK).bodyEq(http.MethodOptions)
+}
+
+func TestSameSiteOnly(t *testing.T) {
+	srv := httptest.NewServer(New(SameSiteOnly())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete), withHeader("Origin", "https://"+host)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete), withHeader("Referer", srv.URL+"/form")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete), withHeader("Origin", "https://evil.example.com")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete), withHeader("Origin", "null")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete), withHeader("Referer", "https://evil.example.com/"+host)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	srv = httptest.NewServer(New(SameOriginOnly())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete), withHeader("Origin", srv.URL)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withFormField("_method", http.MethodDelete), withHeader("Origin", strings.Replace(srv.URL, "http:", "https:", 1))).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestHTTPSOnly(t *testing.T) {
+	srv := httptest.NewServer(New(HTTPSOnly())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	// no trusted proxies, the proto header is not honored.
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("X-Forwarded-Proto", "https")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	srv = httptest.NewServer(New(HTTPSOnly(), TrustedProxies("127.0.0.1/32"))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("X-Forwarded-Proto", "https")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+	srv = httptest.NewServer(New(HTTPSOnly(), TrustedProxies("10.0.0.0/8"))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withHeader("X-Forwarded-Proto", "https")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	tlsSrv := httptest.NewTLSServer(New(HTTPSOnly(""))(http.HandlerFunc(writeMethod)))
+	defer tlsSrv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, tlsSrv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-HTTP-Method", http.MethodDelete)
+
+	resp, err := tlsSrv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if body, _ := ioutil.ReadAll(resp.Body); string(body) != http.MethodDelete {
+		t.Fatalf("expected to receive '%s' but got '%s'", http.MethodDelete, body)
+	}
+
+	srv = httptest.NewServer(New(HTTPSOnly(""))(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("X-Forwarded-Proto", "https")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestSkipBrowsers(t *testing.T) {
+	mo := New(SkipBrowsers())
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("Sec-Fetch-Mode", "cors")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestSkipUpgrades(t *testing.T) {
+	h := New(Methods(http.MethodGet), SkipUpgrades())(http.HandlerFunc(writeMethod))
+
+	for _, tt := range []struct {
+		connection, upgrade string
+		expected            string
+	}{
+		{"", "", http.MethodDelete},
+		{"keep-alive, Upgrade", "websocket", http.MethodGet},
+		{"upgrade", "h2c", http.MethodGet},
+		{"keep-alive", "websocket", http.MethodDelete},
+		{"Upgrade", "", http.MethodDelete},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.Header.Set("X-HTTP-Method", http.MethodDelete)
+		if tt.connection != "" {
+			r.Header.Set("Connection", tt.connection)
+		}
+		if tt.upgrade != "" {
+			r.Header.Set("Upgrade", tt.upgrade)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.expected {
+			t.Fatalf("[%s/%s] expected method: %s but got %s", tt.connection, tt.upgrade, tt.expected, got)
+		}
+	}
+}
+
+func TestSkipIfUserAgent(t *testing.T) {
+	mo := New(SkipIfUserAgent(func(ua string) bool {
+		return strings.HasPrefix(ua, "Mozilla/")
+	}))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("User-Agent", "Mozilla/5.0 (X11; Linux x86_64)")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete), withHeader("User-Agent", "sensor/1.0")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		req      *http.Request
+		opts     []Option
+		expected string
+	}{
+		{httptest.NewRequest(http.MethodPost, "/?_method=delete", nil), nil, http.MethodDelete},
+		{httptest.NewRequest(http.MethodPost, "/", nil), nil, ""},
+		{httptest.NewRequest(http.MethodGet, "/?_method=delete", nil), nil, ""},
+		{httptest.NewRequest(http.MethodPost, "/?_method=delete", nil), []Option{IdempotentOnly(http.MethodPut)}, ""},
+	}
+
+	for i, tt := range tests {
+		method := tt.req.Method
+		if got := Resolve(httptest.NewRecorder(), tt.req, tt.opts...); tt.expected != got {
+			t.Fatalf("[%d] expected resolved method: '%s' but got '%s'", i, tt.expected, got)
+		}
+
+		if got := tt.req.Method; method != got {
+			t.Fatalf("[%d] expected request method: '%s' to be left as it is but got '%s'", i, method, got)
+		}
+	}
+}
+
+func TestOverrideGET(t *testing.T) {
+	mo := New(Methods(http.MethodGet))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"?_method=PROPFIND").
+		statusCode(http.StatusOK).bodyEq("PROPFIND")
+	expect(t, http.MethodGet, srv.URL+"?q=search").
+		statusCode(http.StatusOK).bodyEq(http.MethodGet)
+}
+
+func TestFormBodyMethods(t *testing.T) {
+	mo := New(Methods(http.MethodPut), FormBodyMethods(http.MethodPost))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %s", r.Method, b)
+	})))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withBody("application/x-www-form-urlencoded", "_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq("DELETE _method=DELETE")
+	expect(t, http.MethodPut, srv.URL, withBody("application/x-www-form-urlencoded", "_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq("PUT _method=DELETE")
+	expect(t, http.MethodPut, srv.URL+"?_method=DELETE", withBody("application/x-www-form-urlencoded", "data")).
+		statusCode(http.StatusOK).bodyEq("DELETE data")
+}
+
+func TestDiagnosticsHeader(t *testing.T) {
+	mo := New(DiagnosticsHeader("X-Method-Override-Trace"))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost).headerEq("X-Method-Override-Trace", "header:empty;form:absent;query:absent")
+	expect(t, http.MethodPost, srv.URL+"?_method=", withFormField("name", "value")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost).headerEq("X-Method-Override-Trace", "header:absent;form:empty;query:empty")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("X-Method-Override-Trace", "")
+	expect(t, http.MethodGet, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodGet).headerEq("X-Method-Override-Trace", "")
+}
+
+func TestConsumedBody(t *testing.T) {
+	h := New()(http.HandlerFunc(writeMethod))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/postform":
+			// consume the body and fill the post form only.
+			b, _ := ioutil.ReadAll(r.Body)
+			r.PostForm, _ = url.ParseQuery(string(b))
+		case "/consumed":
+			ioutil.ReadAll(r.Body)
+		case "/closed":
+			r.Body.Close()
+		}
+
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/postform", withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL+"/consumed", withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"/closed", withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestStopAfterSource(t *testing.T) {
+	mo := New(StopAfterSource(SourceHeader))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withHeader("X-HTTP-Method", "")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withHeader("X-HTTP-Method", http.MethodPut)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE").
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	// form sources still fall through to the query.
+	expect(t, http.MethodPost, srv.URL+"?_method=DELETE", withFormField("_method", "")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func TestTrustedProxies(t *testing.T) {
+	mo := New(TrustedProxies("10.0.0.0/8", "::1"))
+	h := mo(http.HandlerFunc(writeMethod))
+
+	tests := []struct {
+		remoteAddr string
+		expected   string
+	}{
+		{"10.1.2.3:1234", http.MethodDelete},
+		{"[::1]:1234", http.MethodDelete},
+		{"192.168.1.1:1234", http.MethodPost},
+		{"[2001:db8::1]:1234", http.MethodPost},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		r.Header.Set("X-HTTP-Method", http.MethodDelete)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if got := w.Body.String(); tt.expected != got {
+			t.Fatalf("%s: expected to receive '%s' but got '%s'", tt.remoteAddr, tt.expected, got)
+		}
+	}
+
+	// Form and query getters are unaffected.
+	r := httptest.NewRequest(http.MethodPost, "/?_method=DELETE", nil)
+	r.RemoteAddr = "192.168.1.1:1234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if expected, got := http.MethodDelete, w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+
+	// Every getter which reads the request headers is skipped too.
+	headerTests := []struct {
+		name   string
+		getter Option
+		header string
+		value  string
+	}{
+		{"accept", AcceptParam("_method"), "Accept", "application/json; _method=DELETE"},
+		{"prefer", PreferParam("method"), "Prefer", "method=DELETE"},
+		{"server var", ServerVar("HTTP_X_HTTP_METHOD"), "X-HTTP-Method", http.MethodDelete},
+		{"group", AnyOf(Headers("X-HTTP-Method")), "X-HTTP-Method", http.MethodDelete},
+	}
+
+	for _, tt := range headerTests {
+		h := New(TrustedProxies("10.0.0.0/8"), Only(tt.getter))(http.HandlerFunc(writeMethod))
+
+		for remoteAddr, expected := range map[string]string{
+			"10.1.2.3:1234":    http.MethodDelete,
+			"192.168.1.1:1234": http.MethodPost,
+		} {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.RemoteAddr = remoteAddr
+			r.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if got := w.Body.String(); expected != got {
+				t.Fatalf("%s: %s: expected to receive '%s' but got '%s'", tt.name, remoteAddr, expected, got)
+			}
+		}
+	}
+
+	if _, err := NewStrict(TrustedProxies("invalid")); err == nil {
+		t.Fatalf("expected an invalid trusted proxy error")
+	}
+}
+
+func TestFallbackMethod(t *testing.T) {
+	mo := New(StrictMethods(), FallbackMethod(http.MethodGet))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", "DELET")).
+		statusCode(http.StatusOK).bodyEq(http.MethodGet)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
+
+func TestHeadersHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(New(Headers("x-custom-header"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Proto, r.Method)
+	})))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	for _, key := range []string{"X-Custom-Header", "x-http-method-override"} {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header[key] = []string{http.MethodDelete}
+
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Request = req
+
+		(&testie{t: t, resp: resp}).statusCode(http.StatusOK).bodyEq("HTTP/2.0 DELETE").
+			headerEq("Vary", textproto.CanonicalMIMEHeaderKey(key))
+	}
+
+	// a stack which does not canonicalize the header keys.
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header["x-http-method"] = []string{http.MethodPut}
+
+	w := httptest.NewRecorder()
+	New()(http.HandlerFunc(writeMethod)).ServeHTTP(w, r)
+	if expected, body := http.MethodPut, w.Body.String(); expected != body {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, body)
+	}
+	if expected, vary := "X-Http-Method", w.Header().Get("Vary"); expected != vary {
+		t.Fatalf("expected Vary: '%s' but got '%s'", expected, vary)
+	}
+}
+
+func TestFragmentHeader(t *testing.T) {
+	mo := New(Only(FragmentHeader("X-Original-URI", "_method")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Original-URI", "/p#_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerEq("Vary", "X-Original-Uri")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Original-URI", "/p?q=1#tab=2&_method=put")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Original-URI", "/p?_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Original-URI", "/p#tab=2")).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	// the header is registered like the `Headers` ones.
+	opts := []Option{Only(Headers("X-HTTP-Method"), FragmentHeader("X-Original-URI", "_method")), VaryAllHeaders()}
+	mo, config := NewWithConfig(opts...)
+	if expected := []string{"X-HTTP-Method", "X-Original-URI"}; !reflect.DeepEqual(expected, config.Headers) {
+		t.Fatalf("expected headers: %v but got %v", expected, config.Headers)
+	}
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost).headerValuesEq("Vary", "X-Http-Method", "X-Original-Uri")
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Original-URI", "/p#_method=DELETE")).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerValuesEq("Vary", "X-Http-Method", "X-Original-Uri")
+}
+
+func TestServerVar(t *testing.T) {
+	mo := New(Only(ServerVar("HTTP_X_HTTP_METHOD")))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+
+	vars := map[string]string{"REQUEST_METHOD_OVERRIDE": http.MethodPut}
+	mo = New(Only(ServerVar("REQUEST_METHOD_OVERRIDE")), ServerVars(func(r *http.Request, name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}))
+
+	srv = httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+	expect(t, http.MethodPost, srv.URL, withHeader("Request-Method-Override", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+}
+
+func TestVaryAllHeaders(t *testing.T) {
+	srv := httptest.NewServer(New(VaryAllHeaders())(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method-Override", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerValuesEq("Vary", "X-Http-Method", "X-Http-Method-Override", "X-Method-Override")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost).headerValuesEq("Vary", "X-Http-Method", "X-Http-Method-Override", "X-Method-Override")
+
+	srv = httptest.NewServer(New()(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method-Override", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete).headerValuesEq("Vary", "X-Http-Method-Override")
+	expect(t, http.MethodPost, srv.URL).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost).headerValuesEq("Vary")
+}
+
+func TestConcurrentGetters(t *testing.T) {
+	started := make(chan struct{})
+
+	mo := New(ConcurrentGetters(), Only(
+		Getter(func(w http.ResponseWriter, r *http.Request) string {
+			select {
+			case <-started: // the next getter is running at the same time.
+				return http.MethodPut
+			case <-time.After(5 * time.Second):
+				return ""
+			}
+		}),
+		Getter(func(w http.ResponseWriter, r *http.Request) string {
+			close(started)
+			return http.MethodDelete
+		}),
+		Headers("X-Custom-Header"),
+	))
+
+	srv := httptest.NewServer(mo(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodPatch)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPut)
+
+	// the handler is called once all the custom getters returned,
+	// even if a previous getter resolved the method.
+	var done int32
+	mo = New(ConcurrentGetters(), Only(
+		Headers("X-Custom-Header"),
+		Getter(func(w http.ResponseWriter, r *http.Request) string {
+			time.Sleep(50 * time.Millisecond)
+			atomic.StoreInt32(&done, 1)
+			return ""
+		}),
+	))
+
+	h := mo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %d", r.Method, atomic.LoadInt32(&done))
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-Custom-Header", http.MethodDelete)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if expected, got := "DELETE 1", w.Body.String(); expected != got {
+		t.Fatalf("expected to receive '%s' but got '%s'", expected, got)
+	}
+}
+
 // Small test suite for this package follows.
 
+func writeMethod(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(r.Method))
+}
+
 func expect(t *testing.T, method, url string, testieOptions ...func(*http.Request)) *testie {
 	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
@@ -132,6 +2650,43 @@ func withFormField(key string, value string) func(*http.Request) {
 	}
 }
 
+func withBody(contentType string, body string) func(*http.Request) {
+	return func(r *http.Request) {
+		enc := strings.NewReader(body)
+		r.Body = ioutil.NopCloser(enc)
+		r.ContentLength = int64(enc.Len())
+
+		r.Header.Set("Content-Type", contentType)
+	}
+}
+
+// withMultipartForm sets a multipart form body of key-value pairs.
+func withMultipartForm(keyValues ...string) func(*http.Request) {
+	return func(r *http.Request) {
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+		for i := 1; i < len(keyValues); i += 2 {
+			mw.WriteField(keyValues[i-1], keyValues[i])
+		}
+		mw.Close()
+
+		r.Body = ioutil.NopCloser(body)
+		r.ContentLength = int64(body.Len())
+
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+	}
+}
+
+func withTrailer(key string, value string) func(*http.Request) {
+	return func(r *http.Request) {
+		if r.Trailer == nil {
+			r.Trailer = make(http.Header)
+		}
+		r.Trailer.Add(key, value)
+		r.ContentLength = -1
+	}
+}
+
 func testReq(t *testing.T, req *http.Request) *testie {
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -155,6 +2710,22 @@ func (te *testie) statusCode(expected int) *testie {
 	return te
 }
 
+func (te *testie) headerEq(key, expected string) *testie {
+	if got := te.resp.Header.Get(key); expected != got {
+		te.t.Fatalf("%s: expected header %s: '%s' but got '%s'", te.resp.Request.URL, key, expected, got)
+	}
+
+	return te
+}
+
+func (te *testie) headerValuesEq(key string, expected ...string) *testie {
+	if got := te.resp.Header[textproto.CanonicalMIMEHeaderKey(key)]; !reflect.DeepEqual(expected, got) && (len(expected) > 0 || len(got) > 0) {
+		te.t.Fatalf("%s: expected header %s: %v but got %v", te.resp.Request.URL, key, expected, got)
+	}
+
+	return te
+}
+
 func (te *testie) bodyEq(expected string) *testie {
 	b, err := ioutil.ReadAll(te.resp.Body)
 	te.resp.Body.Close()
@@ -0,0 +1,77 @@
+// Package methodoverridetest provides utilities for testing
+// the servers which use the method override wrapper.
+package methodoverridetest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+// RequestOption configures a request created by `NewOverrideRequest`.
+type RequestOption func(*http.Request)
+
+// NewOverrideRequest returns a new incoming server request,
+// suitable for passing to an `http.Handler` for testing,
+// configured by the given options, e.g. `WithOverrideHeader`.
+// Like the `httptest.NewRequest`, it panics on an invalid "target".
+//
+// Example Code:
+//
+//	r := NewOverrideRequest(http.MethodPost, "/users/42", WithOverrideForm("_method", http.MethodDelete))
+//	w := httptest.NewRecorder()
+//	handler.ServeHTTP(w, r)
+func NewOverrideRequest(method, target string, opt ...RequestOption) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	for _, o := range opt {
+		o(r)
+	}
+
+	return r
+}
+
+// WithOverrideHeader sets the "name" header to the method to override with.
+func WithOverrideHeader(name, method string) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set(name, method)
+	}
+}
+
+// WithOverrideForm adds the "field" form field to the urlencoded request body.
+// The form fields of a previous `WithOverrideForm` are kept.
+func WithOverrideForm(field, method string) RequestOption {
+	return func(r *http.Request) {
+		form := make(url.Values)
+		if r.Body != nil && r.Header.Get("Content-Type") == formContentType {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				panic("methodoverridetest: read request body: " + err.Error())
+			}
+
+			if form, err = url.ParseQuery(string(b)); err != nil {
+				panic("methodoverridetest: parse request form: " + err.Error())
+			}
+		}
+		form.Add(field, method)
+
+		body := form.Encode()
+		r.Body = ioutil.NopCloser(strings.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Type", formContentType)
+	}
+}
+
+// WithOverrideQuery adds the "param" url parameter to the request URL.
+func WithOverrideQuery(param, method string) RequestOption {
+	return func(r *http.Request) {
+		q := r.URL.Query()
+		q.Add(param, method)
+
+		r.URL.RawQuery = q.Encode()
+		r.RequestURI = r.URL.RequestURI()
+	}
+}
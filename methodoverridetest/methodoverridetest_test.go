@@ -0,0 +1,65 @@
+package methodoverridetest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/methodoverride"
+)
+
+func TestNewOverrideRequest(t *testing.T) {
+	h := methodoverride.New()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	}))
+
+	tests := []struct {
+		name     string
+		opts     []RequestOption
+		expected string
+	}{
+		{"none", nil, http.MethodPost},
+		{"header", []RequestOption{WithOverrideHeader("X-HTTP-Method", http.MethodDelete)}, http.MethodDelete},
+		{"form", []RequestOption{WithOverrideForm("_method", http.MethodPut)}, http.MethodPut},
+		{"query", []RequestOption{WithOverrideQuery("_method", http.MethodPatch)}, http.MethodPatch},
+		{"header before form", []RequestOption{
+			WithOverrideForm("_method", http.MethodPut),
+			WithOverrideHeader("X-HTTP-Method", http.MethodDelete),
+		}, http.MethodDelete},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, NewOverrideRequest(http.MethodPost, "/users/42", tt.opts...))
+
+		if got := w.Body.String(); got != tt.expected {
+			t.Fatalf("[%s] expected method: %s but got %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestWithOverrideForm(t *testing.T) {
+	r := NewOverrideRequest(http.MethodPost, "/", WithOverrideForm("name", "kataras"), WithOverrideForm("_method", http.MethodDelete))
+
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := "_method=DELETE&name=kataras", string(b); expected != got {
+		t.Fatalf("expected body: %s but got %s", expected, got)
+	}
+
+	if expected, got := int64(len(b)), r.ContentLength; expected != got {
+		t.Fatalf("expected content length: %d but got %d", expected, got)
+	}
+}
+
+func TestWithOverrideQuery(t *testing.T) {
+	r := NewOverrideRequest(http.MethodPost, "/users?id=42", WithOverrideQuery("_method", http.MethodDelete))
+
+	if expected, got := "/users?_method=DELETE&id=42", r.RequestURI; expected != got {
+		t.Fatalf("expected request uri: %s but got %s", expected, got)
+	}
+}
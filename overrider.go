@@ -0,0 +1,55 @@
+package methodoverride
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Overrider is a method override wrapper which can be reconfigured at runtime,
+// e.g. on a configuration reload, without re-wrapping the handlers.
+// See `NewOverrider` package-level function for more.
+//
+// It is safe for concurrent use: `Reconfigure` swaps the options atomically,
+// a request always sees either the old or the new options, never a mix of them.
+// Requests already being served keep the options they started with.
+type Overrider struct {
+	mu   sync.RWMutex
+	opts *options
+}
+
+// NewOverrider returns a new reconfigurable method override wrapper.
+// It accepts the same options as the `New` package-level function.
+func NewOverrider(opt ...Option) *Overrider {
+	return &Overrider{opts: newOptions(opt...)}
+}
+
+// Reconfigure replaces the options of the wrapper.
+// The new options are applied on top of the default values,
+// not the previous options.
+func (o *Overrider) Reconfigure(opt ...Option) {
+	opts := newOptions(opt...)
+
+	o.mu.Lock()
+	o.opts = opts
+	o.mu.Unlock()
+}
+
+// Sources returns the kinds of the currently registered getters, by order.
+func (o *Overrider) Sources() []SourceKind {
+	o.mu.RLock()
+	opts := o.opts
+	o.mu.RUnlock()
+
+	return opts.sources()
+}
+
+// Handler wraps the "next" handler, it can be registered on any HTTP server.
+func (o *Overrider) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o.mu.RLock()
+		opts := o.opts
+		o.mu.RUnlock()
+
+		opts.serveHTTP(w, r, next)
+	})
+}
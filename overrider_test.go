@@ -0,0 +1,71 @@
+package methodoverride
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestOverrider(t *testing.T) {
+	mo := NewOverrider(Only(Headers("X-Custom-Header")))
+
+	srv := httptest.NewServer(mo.Handler(http.HandlerFunc(writeMethod)))
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	mo.Reconfigure()
+
+	expect(t, http.MethodPost, srv.URL, withHeader("X-Custom-Header", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+}
+
+func TestOverriderSources(t *testing.T) {
+	mo := NewOverrider()
+
+	if expected, got := []SourceKind{SourceHeader, SourceForm, SourceQuery}, mo.Sources(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected sources: %v but got %v", expected, got)
+	}
+
+	mo.Reconfigure(Only(Getter(func(w http.ResponseWriter, r *http.Request) string { return "" }), Query("m")))
+
+	if expected, got := []SourceKind{SourceCustom, SourceQuery}, mo.Sources(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected sources: %v but got %v", expected, got)
+	}
+}
+
+func TestOverriderConcurrentReconfigure(t *testing.T) {
+	mo := NewOverrider()
+	h := mo.Handler(http.HandlerFunc(writeMethod))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			mo.Reconfigure(Methods(http.MethodPut))
+		}()
+
+		go func() {
+			defer wg.Done()
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("X-HTTP-Method", http.MethodDelete)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if got := w.Body.String(); got != http.MethodDelete {
+				t.Errorf("expected to receive '%s' but got '%s'", http.MethodDelete, got)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,45 @@
+package methodoverride
+
+import "net/http"
+
+// Preset is an immutable set of options which can be shared
+// between method override wrappers with overlapping rules.
+// See `NewPreset` package-level function for more.
+//
+// Example Code:
+//
+//	base := NewPreset(Methods(http.MethodPut), SaveOriginalMethod("_originalMethod"))
+//	api := base.With(Only(Headers("X-HTTP-Method")))
+//	web := base.With(Only(FormField("_method")))
+//
+//	apiRouter = api.Handler()(apiRouter)
+//	webRouter = web.Handler()(webRouter)
+type Preset struct {
+	opts []Option
+}
+
+// NewPreset returns a new preset of the given options.
+func NewPreset(opt ...Option) *Preset {
+	return &Preset{opts: append([]Option(nil), opt...)}
+}
+
+// With returns a new preset of this preset's options followed by the "extra" ones.
+// This preset is not modified.
+func (p *Preset) With(extra ...Option) *Preset {
+	opts := make([]Option, 0, len(p.opts)+len(extra))
+	opts = append(opts, p.opts...)
+	opts = append(opts, extra...)
+	return &Preset{opts: opts}
+}
+
+// Options returns a copy of the preset's options,
+// e.g. to pass them to the `New` package-level function.
+func (p *Preset) Options() []Option {
+	return append([]Option(nil), p.opts...)
+}
+
+// Handler returns a new method override wrapper of the preset's options.
+// See `New` package-level function for more.
+func (p *Preset) Handler() func(next http.Handler) http.Handler {
+	return New(p.opts...)
+}
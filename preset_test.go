@@ -0,0 +1,35 @@
+package methodoverride
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreset(t *testing.T) {
+	base := NewPreset(Methods(http.MethodPut))
+	api := base.With(Only(Headers("X-Custom-Header")))
+	web := base.With(Only(FormField("_method")))
+
+	apiSrv := httptest.NewServer(api.Handler()(http.HandlerFunc(writeMethod)))
+	defer apiSrv.Close()
+	webSrv := httptest.NewServer(web.Handler()(http.HandlerFunc(writeMethod)))
+	defer webSrv.Close()
+	baseSrv := httptest.NewServer(base.Handler()(http.HandlerFunc(writeMethod)))
+	defer baseSrv.Close()
+
+	expect(t, http.MethodPut, apiSrv.URL, withHeader("X-Custom-Header", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, apiSrv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	expect(t, http.MethodPut, webSrv.URL, withFormField("_method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, webSrv.URL, withHeader("X-Custom-Header", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+
+	expect(t, http.MethodPut, baseSrv.URL, withHeader("X-HTTP-Method", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodDelete)
+	expect(t, http.MethodPost, baseSrv.URL, withHeader("X-Custom-Header", http.MethodDelete)).
+		statusCode(http.StatusOK).bodyEq(http.MethodPost)
+}
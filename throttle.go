@@ -0,0 +1,88 @@
+package methodoverride
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// throttle limits the overrides per key to "max" per fixed window of "per" duration,
+// see `ThrottleOverrides`.
+type throttle struct {
+	max     int
+	per     time.Duration
+	keyFunc func(*http.Request) string
+	now     func() time.Time
+
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func newThrottle(max int, per time.Duration, keyFunc func(*http.Request) string) *throttle {
+	if keyFunc == nil {
+		keyFunc = remoteHost
+	}
+
+	return &throttle{
+		max:     max,
+		per:     per,
+		keyFunc: keyFunc,
+		now:     time.Now,
+		windows: make(map[string]*window),
+	}
+}
+
+// allow reports whether the request can be overridden and, if so, counts it.
+func (t *throttle) allow(r *http.Request) bool {
+	key := t.keyFunc(r)
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastSweep) >= t.per {
+		t.sweep(now)
+	}
+
+	win, ok := t.windows[key]
+	if !ok || now.Sub(win.start) >= t.per {
+		win = &window{start: now}
+		t.windows[key] = win
+	}
+
+	if win.count >= t.max {
+		return false
+	}
+
+	win.count++
+	return true
+}
+
+// sweep evicts the expired windows, so the map does not grow unbounded.
+func (t *throttle) sweep(now time.Time) {
+	for key, win := range t.windows {
+		if now.Sub(win.start) >= t.per {
+			delete(t.windows, key)
+		}
+	}
+
+	t.lastSweep = now
+}
+
+// remoteHost returns the host of the request's remote address,
+// the default key of the throttle.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,38 @@
+package methodoverride
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestThrottleWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	th := newThrottle(2, time.Minute, nil)
+	th.now = func() time.Time { return now }
+
+	a := httptest.NewRequest(http.MethodPost, "/", nil)
+	a.RemoteAddr = "10.0.0.1:1234"
+	b := httptest.NewRequest(http.MethodPost, "/", nil)
+	b.RemoteAddr = "10.0.0.2:1234"
+
+	for i, expected := range []bool{true, true, false} {
+		if got := th.allow(a); expected != got {
+			t.Fatalf("[%d] expected allow: %v but got %v", i, expected, got)
+		}
+	}
+
+	if !th.allow(b) {
+		t.Fatalf("expected a different client to be allowed")
+	}
+
+	now = now.Add(time.Minute)
+	if !th.allow(a) {
+		t.Fatalf("expected the client to be allowed on the next window")
+	}
+
+	if expected, got := 1, len(th.windows); expected != got {
+		t.Fatalf("expected %d windows after the sweep but got %d", expected, got)
+	}
+}